@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// projectsAPIURL is the JSON endpoint Freelancer's own search page calls for
+// active projects. It needs no authentication for public listings.
+const projectsAPIURL = "https://www.freelancer.com/api/projects/0.1/projects/active/"
+
+// apiSortFields maps --sort values onto the API's sort_field and whether the
+// order is reversed.
+var apiSortFields = map[string]struct {
+	field   string
+	reverse bool
+}{
+	"latest":       {"time_updated", true},
+	"oldest":       {"time_updated", false},
+	"lowestPrice":  {"bid_avg_usd", false},
+	"highestPrice": {"bid_avg_usd", true},
+	"fewestBids":   {"bid_count", false},
+	"mostBids":     {"bid_count", true},
+}
+
+// maxAPIPageSize is the most projects the API returns per request.
+const maxAPIPageSize = 100
+
+// apiPageSize is how many projects each API request asks for: --per-page, or
+// as many as an HTML page shows.
+func apiPageSize() int {
+	if perPage > 0 {
+		return perPage
+	}
+	return resultsPerPage
+}
+
+// buildAPIURL is the --use-api counterpart of buildURL, expressing the same
+// filters in the projects API's parameter names.
+func buildAPIURL(query string, page int) string {
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(apiPageSize()))
+	q.Set("offset", strconv.Itoa((max(page, 1)-1)*apiPageSize()))
+	q.Set("job_details", "true")
+	// The owners' details carry the employer's country.
+	q.Set("user_details", "true")
+	q.Set("user_country_details", "true")
+
+	for _, t := range strings.Split(pTypes, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			q.Add("project_types[]", t)
+		}
+	}
+	for _, c := range clientCountries {
+		q.Add("countries[]", c)
+	}
+	if skills != "all" {
+		for _, id := range strings.Split(skills, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				q.Add("jobs[]", id)
+			}
+		}
+	}
+	if priceFlagSet("fixedMin", fixedPriceMin) {
+		q.Set("min_price", strconv.Itoa(fixedPriceMin))
+	}
+	if priceFlagSet("fixedMax", fixedPriceMax) {
+		q.Set("max_price", strconv.Itoa(fixedPriceMax))
+	}
+	if priceFlagSet("hourlyMin", hourlyRateMin) {
+		q.Set("min_hourly_rate", strconv.Itoa(hourlyRateMin))
+	}
+	if priceFlagSet("hourlyMax", hourlyRateMax) {
+		q.Set("max_hourly_rate", strconv.Itoa(hourlyRateMax))
+	}
+	for _, upgrade := range projectUpgrades {
+		if *onlyUpgrades[upgrade] {
+			if upgrade == "nda" {
+				upgrade = "NDA"
+			}
+			q.Add("project_upgrades[]", upgrade)
+		}
+	}
+	if sort, ok := apiSortFields[sortOption]; ok {
+		q.Set("sort_field", sort.field)
+		if sort.reverse {
+			q.Set("reverse_sort", "true")
+		}
+	}
+	if query != "" {
+		q.Set("query", query)
+	}
+	return projectsAPIURL + "?" + q.Encode()
+}
+
+// apiProject is the subset of the API's project object that maps onto
+// Project.
+type apiProject struct {
+	ID                 int64  `json:"id"`
+	OwnerID            int64  `json:"owner_id"`
+	Title              string `json:"title"`
+	SEOURL             string `json:"seo_url"`
+	Type               string `json:"type"`
+	PreviewDescription string `json:"preview_description"`
+	TimeSubmitted      int64  `json:"time_submitted"`
+	BidPeriod          int    `json:"bidperiod"`
+	Currency           struct {
+		Code string `json:"code"`
+		Sign string `json:"sign"`
+	} `json:"currency"`
+	Budget struct {
+		Minimum float64 `json:"minimum"`
+		Maximum float64 `json:"maximum"`
+	} `json:"budget"`
+	Jobs []struct {
+		Name string `json:"name"`
+	} `json:"jobs"`
+	// Local is set for on-site projects, whose location is where the work
+	// is rather than only the employer's country.
+	Local    bool `json:"local"`
+	Location struct {
+		City    string `json:"city"`
+		Country struct {
+			Code string `json:"code"`
+			Name string `json:"name"`
+		} `json:"country"`
+	} `json:"location"`
+	BidStats struct {
+		BidCount int     `json:"bid_count"`
+		BidAvg   float64 `json:"bid_avg"`
+	} `json:"bid_stats"`
+	Upgrades struct {
+		Sealed bool `json:"sealed"`
+	} `json:"upgrades"`
+}
+
+// apiUser is the subset of the API's user object, returned with
+// user_details, that describes a project's owner.
+type apiUser struct {
+	Location struct {
+		Country struct {
+			Code string `json:"code"`
+			Name string `json:"name"`
+		} `json:"country"`
+	} `json:"location"`
+}
+
+// scrapeAPI is Scrape for --use-api: it fetches one page of the projects API
+// and maps the JSON into Projects. Everything the HTML cards show is
+// available, so no selectors are involved.
+func scrapeAPI(opts Options, client *http.Client) (*PageResult, error) {
+	req, err := http.NewRequestWithContext(opts.context(), "GET", opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if opts.Cookie != "" {
+		req.Header.Set("Cookie", opts.Cookie)
+	}
+	if opts.Locale != "" {
+		req.Header.Set("Accept-Language", opts.Locale)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkChallenge(resp); err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Result  struct {
+			Projects   []apiProject       `json:"projects"`
+			Users      map[string]apiUser `json:"users"`
+			TotalCount int                `json:"total_count"`
+		} `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding API response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != 200 || body.Status != "success" {
+		return nil, fmt.Errorf("API error: %d %s %s", resp.StatusCode, body.Status, body.Message)
+	}
+
+	result := &PageResult{
+		Cards:        len(body.Result.Projects),
+		NoResults:    len(body.Result.Projects) == 0,
+		TotalResults: body.Result.TotalCount,
+		TotalPages:   (body.Result.TotalCount + apiPageSize() - 1) / apiPageSize(),
+	}
+	now := time.Now()
+	for i, ap := range body.Result.Projects {
+		p := ap.project(now, body.Result.Users[strconv.FormatInt(ap.OwnerID, 10)])
+		p.Position = i + 1
+		if opts.OnProject != nil {
+			keep, err := opts.OnProject(p)
+			if err != nil {
+				return nil, err
+			}
+			if !keep {
+				continue
+			}
+		}
+		result.Projects = append(result.Projects, p)
+	}
+	return result, nil
+}
+
+// project maps an API project and its owner onto Project, rendering the
+// text fields the way the HTML cards show them.
+func (ap apiProject) project(now time.Time, owner apiUser) Project {
+	code, sign := ap.Currency.Code, ap.Currency.Sign
+	money := func(v float64) string {
+		return sign + strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	budget := money(ap.Budget.Minimum)
+	if ap.Budget.Maximum > ap.Budget.Minimum {
+		budget += " - " + money(ap.Budget.Maximum)
+	}
+	budget += " " + code
+	priceType := priceTypeFixed
+	if ap.Type == priceTypeHourly {
+		priceType = priceTypeHourly
+		budget += " / hr"
+	}
+
+	var avgBid string
+	if ap.Upgrades.Sealed {
+		ap.BidStats.BidAvg = 0
+	}
+	if ap.BidStats.BidAvg > 0 {
+		avgBid = money(ap.BidStats.BidAvg) + " " + code
+	}
+
+	p := Project{
+		Title:            ap.Title,
+		Link:             resolveLink("/projects/" + ap.SEOURL),
+		CanonicalLink:    canonicalLink("/projects/" + ap.SEOURL),
+		Budget:           budget,
+		BudgetMin:        ap.Budget.Minimum,
+		BudgetMax:        ap.Budget.Maximum,
+		Currency:         code,
+		PriceType:        priceType,
+		AverageBid:       avgBid,
+		AverageBidAmount: ap.BidStats.BidAvg,
+		Sealed:           ap.Upgrades.Sealed,
+		BidsCount:        bidsText(ap.BidStats.BidCount),
+		BidsCountNum:     &ap.BidStats.BidCount,
+		Description:      cleanText(ap.PreviewDescription),
+	}
+	if preserveFormatting {
+		p.Description = tidyLines(ap.PreviewDescription)
+	}
+	for _, job := range ap.Jobs {
+		p.Skills = append(p.Skills, job.Name)
+	}
+	// The project's own location is where on-site work is, not where the
+	// employer is.
+	country := owner.Location.Country
+	if p.EmployerCountry = countryCode(country.Code); p.EmployerCountry == "" {
+		p.EmployerCountry = countryCode(country.Name)
+	}
+	p.HasEmployerInfo = p.EmployerCountry != ""
+	if ap.Local {
+		p.Location = strings.Trim(ap.Location.City+", "+ap.Location.Country.Name, ", ")
+	}
+	p.ValueScore = valueScore(p)
+	if ap.TimeSubmitted > 0 {
+		p.PostedAt = time.Unix(ap.TimeSubmitted, 0)
+		if ap.BidPeriod > 0 {
+			left := p.PostedAt.AddDate(0, 0, ap.BidPeriod).Sub(now)
+			switch {
+			case left <= 0:
+				p.TimeLeft = "Ended"
+				p.Closed = true
+			case left < 24*time.Hour:
+				p.TimeLeft = fmt.Sprintf("%d hours left", int(left.Hours()))
+			default:
+				p.TimeLeft = fmt.Sprintf("%d days left", int(left.Hours()/24))
+			}
+		}
+	}
+	return p
+}
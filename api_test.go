@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// apiResponse is a projects API reply with one on-site project in Germany
+// posted by an employer in the United States, and one whose owner isn't
+// among the users.
+const apiResponse = `{
+  "status": "success",
+  "result": {
+    "total_count": 2,
+    "projects": [
+      {"id": 1, "owner_id": 10, "title": "Install shelves", "seo_url": "carpentry/install-shelves",
+       "type": "fixed", "currency": {"code": "EUR", "sign": "€"}, "budget": {"minimum": 100, "maximum": 200},
+       "local": true, "location": {"city": "Berlin", "country": {"code": "de", "name": "Germany"}}},
+      {"id": 2, "owner_id": 20, "title": "Write a script", "seo_url": "python/write-a-script",
+       "type": "fixed", "currency": {"code": "USD", "sign": "$"}, "budget": {"minimum": 30, "maximum": 250}}
+    ],
+    "users": {
+      "10": {"location": {"country": {"code": "us", "name": "United States"}}}
+    }
+  }
+}`
+
+func TestScrapeAPIEmployerCountry(t *testing.T) {
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(apiResponse))
+	}))
+	t.Cleanup(srv.Close)
+	resetFlags(t)
+	apiURL := buildAPIURL("", 1)
+	_, query, _ := strings.Cut(apiURL, "?")
+
+	result, err := Scrape(Options{URL: srv.URL + "?" + query, Client: srv.Client(), API: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(gotQuery, "user_details=true") || !strings.Contains(gotQuery, "user_country_details=true") {
+		t.Errorf("API query %q doesn't ask for the owners' countries", gotQuery)
+	}
+	if len(result.Projects) != 2 {
+		t.Fatalf("got %d projects, want 2", len(result.Projects))
+	}
+	shelves, script := result.Projects[0], result.Projects[1]
+	if shelves.EmployerCountry != "us" || !shelves.HasEmployerInfo {
+		t.Errorf("on-site project: employer_country = %q, has_employer_info = %v; want the owner's us", shelves.EmployerCountry, shelves.HasEmployerInfo)
+	}
+	if shelves.Location != "Berlin, Germany" {
+		t.Errorf("on-site project: location = %q, want Berlin, Germany", shelves.Location)
+	}
+	if script.EmployerCountry != "" || script.HasEmployerInfo {
+		t.Errorf("unknown owner: employer_country = %q, has_employer_info = %v; want none", script.EmployerCountry, script.HasEmployerInfo)
+	}
+}
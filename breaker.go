@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// circuitBreaker stops a batch run from issuing requests once too many have
+// failed in a row, since a run of 429s or 403s usually means Freelancer is
+// throttling or blocking and more requests only make that worse. Any success
+// resets the count.
+type circuitBreaker struct {
+	threshold int
+	trip      func()
+
+	mu       sync.Mutex
+	failures int
+	opened   bool
+}
+
+// circuit is the breaker of the current scrape run; nil when disabled.
+var circuit *circuitBreaker
+
+// newCircuitBreaker returns a breaker that calls trip once after threshold
+// consecutive failures, or nil if threshold isn't positive.
+func newCircuitBreaker(threshold int, trip func()) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &circuitBreaker{threshold: threshold, trip: trip}
+}
+
+// record counts the outcome of one request.
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold && !b.opened {
+		b.opened = true
+		log.Printf("Circuit opened after %d consecutive failed requests; not sending any more.", b.failures)
+		b.trip()
+	}
+}
+
+// isOpen reports whether the breaker has tripped.
+func (b *circuitBreaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.opened
+}
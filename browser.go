@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	// openLinksConfirm is how many links --open-links opens before asking
+	// first.
+	openLinksConfirm = 10
+	// openLinksMax caps --open-links however many are asked for.
+	openLinksMax = 50
+)
+
+// openLinks opens the links of the first n projects in the default browser.
+// Above openLinksConfirm links it asks on r first; n is capped at
+// openLinksMax.
+func openLinks(projects []Project, n int, r io.Reader, w io.Writer) {
+	if n > openLinksMax {
+		log.Printf("Warning: --open-links capped at %d", openLinksMax)
+		n = openLinksMax
+	}
+	n = min(n, len(projects))
+	if n == 0 {
+		return
+	}
+	if n > openLinksConfirm {
+		fmt.Fprintf(w, "Open %d project pages in your browser? [y/N] ", n)
+		answer, _ := bufio.NewReader(r).ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			return
+		}
+	}
+	for _, p := range projects[:n] {
+		if p.Link == "" {
+			continue
+		}
+		if err := openBrowser(p.Link); err != nil {
+			log.Printf("Warning: could not open %s: %v", p.Link, err)
+		}
+	}
+}
+
+// openBrowser opens url with the platform's default handler without
+// waiting for the browser to exit.
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	cmd.Stdout, cmd.Stderr = nil, os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
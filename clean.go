@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Default output files are named outputPrefix + the time in outputTimeLayout
+// + their extension, e.g. freelancer.com_15-04-05_02-01-2006.csv.
+const (
+	outputPrefix     = "freelancer.com_"
+	outputTimeLayout = "15-04-05_02-01-2006"
+)
+
+// defaultOutputPattern matches exactly the names handleOutput gives files
+// when -O isn't set, optionally gzipped.
+var defaultOutputPattern = regexp.MustCompile(`^freelancer\.com_(\d{2}-\d{2}-\d{2}_\d{2}-\d{2}-\d{4})\.[a-z0-9]+(\.gz)?$`)
+
+var (
+	cleanDir       string
+	cleanOlderThan time.Duration
+	cleanDryRun    bool
+)
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Delete old timestamped output files",
+	Long: `Delete the timestamped output files flparser writes when -O isn't given
+(freelancer.com_<time>.<ext>) once they are older than --older-than, judged
+by the time in their name. Files that don't match that naming exactly are
+never touched. Use --dry-run to see what would be deleted.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		removed, err := cleanOutputs(cleanDir, time.Now().Add(-cleanOlderThan), cleanDryRun)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		verb := "Deleted"
+		if cleanDryRun {
+			verb = "Would delete"
+		}
+		fmt.Printf("%s %d files.\n", verb, removed)
+	},
+}
+
+// cleanOutputs deletes, or with dryRun lists, the default-named output files
+// in dir whose name says they were written before cutoff.
+func cleanOutputs(dir string, cutoff time.Time, dryRun bool) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		m := defaultOutputPattern.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		written, err := time.ParseInLocation(outputTimeLayout, m[1], time.Local)
+		if err != nil || !written.Before(cutoff) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if dryRun {
+			fmt.Println(path)
+			removed++
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Printf("Warning: could not delete %s: %v", path, err)
+			continue
+		}
+		fmt.Println("Deleted:", path)
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// commandFlags are the scraping flags reproduceCommand reads; main sets them.
+var commandFlags *pflag.FlagSet
+
+// commandSkipFlags are left out of the reproduced command line: they carry
+// secrets or only affect this particular invocation.
+var commandSkipFlags = map[string]bool{
+	"cookie":     true,
+	"print-cmd":  true,
+	"resume":     true,
+	"reset-seen": true,
+	"har-file":   true,
+
+	"print-effective-config": true,
+	// --url is reproduced by the search flags it's expanded into.
+	"url": true,
+}
+
+// reproduceCommand returns an flparser command line that repeats the
+// current search: every flag given explicitly, sorted by name and quoted
+// for a POSIX shell, with passwords in URLs masked. Flags left at their
+// defaults are omitted since they mean the same thing to the same version.
+func reproduceCommand(flags *pflag.FlagSet) string {
+	if flags == nil {
+		return ""
+	}
+	var args []string
+	flags.Visit(func(f *pflag.Flag) {
+		if commandSkipFlags[f.Name] {
+			return
+		}
+		// Array flags don't split on commas, so each value is repeated.
+		if sv, ok := f.Value.(pflag.SliceValue); ok && f.Value.Type() == "stringArray" {
+			for _, v := range sv.GetSlice() {
+				args = append(args, "--"+f.Name+"="+shellQuote(redactURL(v)))
+			}
+			return
+		}
+		value := f.Value.String()
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			value = strings.Join(sv.GetSlice(), ",")
+		}
+		if f.Value.Type() == "bool" && value == "true" {
+			args = append(args, "--"+f.Name)
+			return
+		}
+		args = append(args, "--"+f.Name+"="+shellQuote(redactURL(value)))
+	})
+	sort.Strings(args)
+	return strings.Join(append([]string{"flparser"}, args...), " ")
+}
+
+// redactURL masks the password in a URL value such as --publish's
+// redis://:secret@host, leaving any other value alone.
+func redactURL(s string) string {
+	if strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return s
+}
+
+// shellQuote quotes s for a POSIX shell when it contains anything beyond
+// plainly safe characters.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.,/:=+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
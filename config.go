@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"github.com/spf13/pflag"
+)
+
+// configSetting is one flag's value as --print-effective-config shows it,
+// with where the value came from: "flag", "preset" or "default".
+type configSetting struct {
+	Value  any    `json:"value"`
+	Source string `json:"source"`
+}
+
+// secretFlags have their values hidden from --print-effective-config.
+var secretFlags = map[string]bool{
+	"cookie": true,
+}
+
+// printEffectiveConfig writes every flag of flags as JSON, keyed by name,
+// with the values in effect once presets have been applied.
+func printEffectiveConfig(w io.Writer, flags *pflag.FlagSet) error {
+	config := make(map[string]configSetting)
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Name == "help" {
+			return
+		}
+		source := "default"
+		if f.Changed {
+			source = "flag"
+		}
+		value := configValue(f)
+		if f.Name == "skills" && preset != "" {
+			source = "preset " + preset
+			value = skills
+		}
+		if secretFlags[f.Name] && f.Value.String() != "" {
+			value = "REDACTED"
+		}
+		config[f.Name] = configSetting{Value: value, Source: source}
+	})
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(config)
+}
+
+// configValue returns f's value as its JSON type, with any password in a
+// URL value masked.
+func configValue(f *pflag.Flag) any {
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		return sv.GetSlice()
+	}
+	s := f.Value.String()
+	switch f.Value.Type() {
+	case "bool":
+		if v, err := strconv.ParseBool(s); err == nil {
+			return v
+		}
+	case "int", "int64", "float64":
+		return json.Number(s)
+	}
+	return redactURL(s)
+}
@@ -0,0 +1,121 @@
+package main
+
+import "strings"
+
+// countryNames maps the lowercase ISO 3166-1 alpha-2 codes accepted by
+// --clientCountries to English country names.
+var countryNames = map[string]string{
+	"ae": "United Arab Emirates",
+	"ar": "Argentina",
+	"at": "Austria",
+	"au": "Australia",
+	"bd": "Bangladesh",
+	"be": "Belgium",
+	"bg": "Bulgaria",
+	"br": "Brazil",
+	"ca": "Canada",
+	"ch": "Switzerland",
+	"cl": "Chile",
+	"cn": "China",
+	"co": "Colombia",
+	"cy": "Cyprus",
+	"cz": "Czech Republic",
+	"de": "Germany",
+	"dk": "Denmark",
+	"ee": "Estonia",
+	"eg": "Egypt",
+	"es": "Spain",
+	"fi": "Finland",
+	"fr": "France",
+	"gb": "United Kingdom",
+	"gr": "Greece",
+	"hk": "Hong Kong",
+	"hr": "Croatia",
+	"hu": "Hungary",
+	"id": "Indonesia",
+	"ie": "Ireland",
+	"il": "Israel",
+	"in": "India",
+	"is": "Iceland",
+	"it": "Italy",
+	"jp": "Japan",
+	"ke": "Kenya",
+	"kr": "South Korea",
+	"kw": "Kuwait",
+	"lt": "Lithuania",
+	"lu": "Luxembourg",
+	"lv": "Latvia",
+	"ma": "Morocco",
+	"mt": "Malta",
+	"mx": "Mexico",
+	"my": "Malaysia",
+	"ng": "Nigeria",
+	"nl": "Netherlands",
+	"no": "Norway",
+	"nz": "New Zealand",
+	"pe": "Peru",
+	"ph": "Philippines",
+	"pk": "Pakistan",
+	"pl": "Poland",
+	"pt": "Portugal",
+	"qa": "Qatar",
+	"ro": "Romania",
+	"rs": "Serbia",
+	"sa": "Saudi Arabia",
+	"se": "Sweden",
+	"sg": "Singapore",
+	"si": "Slovenia",
+	"sk": "Slovakia",
+	"th": "Thailand",
+	"tr": "Turkey",
+	"tw": "Taiwan",
+	"ua": "Ukraine",
+	"us": "United States",
+	"vn": "Vietnam",
+	"za": "South Africa",
+}
+
+// countryCode returns the lowercase ISO code for a country given either as a
+// code or as its English name, or "" when it isn't in countryNames.
+func countryCode(s string) string {
+	s = strings.TrimSpace(s)
+	if _, ok := countryNames[strings.ToLower(s)]; ok {
+		return strings.ToLower(s)
+	}
+	for code, name := range countryNames {
+		if strings.EqualFold(name, s) {
+			return code
+		}
+	}
+	return ""
+}
+
+// countryFlag returns the flag emoji for a two-letter country code, built
+// from regional indicator symbols, or "" for anything else.
+func countryFlag(code string) string {
+	if len(code) != 2 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r < 'A' || r > 'Z' {
+			return ""
+		}
+		sb.WriteRune(0x1F1E6 + r - 'A')
+	}
+	return sb.String()
+}
+
+// countryLabel renders a country code for human-readable output: the
+// English name, or the upper-cased code if the name isn't known, preceded
+// by the flag unless --flag-emoji=false.
+func countryLabel(code string) string {
+	label := strings.ToUpper(code)
+	if name, ok := countryNames[strings.ToLower(code)]; ok {
+		label = name
+	}
+	if flag := countryFlag(code); flagEmoji && flag != "" {
+		label = flag + " " + label
+	}
+	return label
+}
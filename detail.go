@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"golang.org/x/net/html"
+)
+
+// detailDescriptionSelector matches the full description on a project page.
+const detailDescriptionSelector = ".PageProjectViewLogout-detail-paragraph, .ProjectDescription, [itemprop=description]"
+
+// detailAttachmentSelector matches each attached file in a project page's
+// attachment list, detailAttachmentListSelector; detailAttachmentLinkSelector
+// matches the links to them, which are counted instead on pages without the
+// list.
+const (
+	detailAttachmentSelector     = ".AttachmentsList-item, .ProjectViewDetailsAttachments-item"
+	detailAttachmentListSelector = ".AttachmentsList, .ProjectViewDetailsAttachments"
+	detailAttachmentLinkSelector = "a[href*='/attachments/']"
+)
+
+// Compiled selectors for readDetailPage.
+var (
+	detailDescriptionMatcher    = cascadia.MustCompile(detailDescriptionSelector)
+	detailAttachmentMatcher     = cascadia.MustCompile(detailAttachmentSelector)
+	detailAttachmentListMatcher = cascadia.MustCompile(detailAttachmentListSelector)
+)
+
+// detailFieldNames are the project page fields --detail-fields can select.
+var detailFieldNames = []string{"description", "attachments"}
+
+// wantDetail reports whether --detail-fields selects field; all fields are
+// extracted when it's not given.
+func wantDetail(field string) bool {
+	return len(detailFields) == 0 || slices.Contains(detailFields, field)
+}
+
+// projectDetail is what a project page adds to its card.
+type projectDetail struct {
+	description     string
+	descriptionHTML string
+	attachments     int
+	err             error
+}
+
+// detailCache holds the project pages fetched during this run, keyed by
+// projectKey, so a project that turns up more than once, through another
+// link or query, is fetched once.
+var detailCache = struct {
+	sync.Mutex
+	entries map[string]*detailEntry
+}{entries: make(map[string]*detailEntry)}
+
+type detailEntry struct {
+	once   sync.Once
+	detail projectDetail
+}
+
+// cachedDetail returns the details of p's page at link, fetching it only the
+// first time the project is asked for; concurrent callers wait for that
+// fetch.
+func cachedDetail(ctx context.Context, client *http.Client, p Project, link string) projectDetail {
+	key := projectKey(p)
+	detailCache.Lock()
+	e, ok := detailCache.entries[key]
+	if !ok {
+		e = &detailEntry{}
+		detailCache.entries[key] = e
+	}
+	detailCache.Unlock()
+	e.once.Do(func() { e.detail = fetchDetail(ctx, client, link) })
+	return e.detail
+}
+
+// fetchDetails visits each project's page for --full-description, replacing
+// the card's shortened description with the full text (the snippet is kept
+// as Summary) and counting the attached files, which cards don't always
+// show; --detail-fields fetches only one of the two, reading each page just
+// far enough for it (see readDetailPage). Each page is fetched once per run
+// (see detailCache), and up to --query-concurrency pages at once. A page
+// that fails keeps the card's data. When ctx is cancelled, as on the first
+// Ctrl-C, pages still in flight are abandoned and the rest aren't requested,
+// leaving their projects as the cards had them.
+func fetchDetails(ctx context.Context, client *http.Client, projects []Project) {
+	sem := make(chan struct{}, max(queryWorkers, 1))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed, skipped := 0, 0
+	for i := range projects {
+		link := detailURL(projects[i])
+		if link == "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				mu.Lock()
+				skipped++
+				mu.Unlock()
+				return
+			}
+			d := cachedDetail(ctx, client, projects[i], link)
+			if d.err != nil && ctx.Err() != nil {
+				mu.Lock()
+				skipped++
+				mu.Unlock()
+				return
+			}
+			if d.err != nil {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				log.Printf("Warning: couldn't fetch details for %s: %v", link, d.err)
+				return
+			}
+			p := &projects[i]
+			if d.description != "" {
+				if d.description != p.Description {
+					p.Summary = p.Description
+				}
+				p.Description = d.description
+				p.DescriptionHTML = d.descriptionHTML
+			}
+			p.Attachments = max(p.Attachments, d.attachments)
+		}()
+	}
+	wg.Wait()
+	switch {
+	case skipped > 0:
+		fmt.Printf("Interrupted; fetched details for %d of %d projects.\n", len(projects)-failed-skipped, len(projects))
+	case failed > 0:
+		fmt.Printf("Fetched details for %d of %d projects.\n", len(projects)-failed, len(projects))
+	}
+}
+
+// detailURL returns the address of p's project page: its link when that's
+// absolute, or else its canonical link, which is absolute even when
+// --relative-links kept the link as a path.
+func detailURL(p Project) string {
+	if u, err := url.Parse(p.Link); err == nil && u.IsAbs() {
+		return p.Link
+	}
+	if p.CanonicalLink != "" {
+		return p.CanonicalLink
+	}
+	return canonicalLink(p.Link)
+}
+
+// fetchDetail fetches and parses one project page.
+func fetchDetail(ctx context.Context, client *http.Client, link string) projectDetail {
+	req, err := http.NewRequestWithContext(ctx, "GET", link, nil)
+	if err != nil {
+		return projectDetail{err: err}
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if cookie != "" {
+		req.Header.Set("Cookie", cookie)
+	}
+	if locale != "" {
+		req.Header.Set("Accept-Language", locale)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return projectDetail{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		if err := checkChallenge(resp); err != nil {
+			return projectDetail{err: err}
+		}
+		return projectDetail{err: fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)}
+	}
+	page, err := readDetailPage(resp.Body)
+	if err != nil {
+		return projectDetail{err: err}
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return projectDetail{err: err}
+	}
+	var d projectDetail
+	if wantDetail("description") {
+		node := doc.Find(detailDescriptionSelector).First()
+		if d.description = descriptionFromNode(node); d.description != "" && keepHTML {
+			d.descriptionHTML = sanitizeHTML(node.Get(0))
+		}
+	}
+	if wantDetail("attachments") {
+		d.attachments = doc.Find(detailAttachmentSelector).Length()
+		if d.attachments == 0 {
+			d.attachments = doc.Find(detailAttachmentLinkSelector).Length()
+		}
+	}
+	return d
+}
+
+// detailPart is an element of a project page that readDetailPage reads up
+// to the end of.
+type detailPart struct {
+	matcher   cascadia.Matcher
+	needsItem bool   // only an element holding an attachment counts
+	tag       string // the matched element's tag, once it's found
+	depth     int    // how many elements named tag are open inside it
+	hasItem   bool
+	done      bool
+}
+
+// readDetailPage reads a project page from r only as far as the fields
+// --detail-fields selects need: to the end of the first description, and
+// of the first attachment list holding any files. The rest isn't
+// downloaded. It reads the whole page when a part is missing, so the
+// attachment links can be counted instead.
+func readDetailPage(r io.Reader) ([]byte, error) {
+	var parts []*detailPart
+	if wantDetail("description") {
+		parts = append(parts, &detailPart{matcher: detailDescriptionMatcher})
+	}
+	if wantDetail("attachments") {
+		parts = append(parts, &detailPart{matcher: detailAttachmentListMatcher, needsItem: true})
+	}
+
+	var buf bytes.Buffer
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				return buf.Bytes(), nil
+			}
+			return nil, z.Err()
+		}
+		buf.Write(z.Raw())
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			n := &html.Node{Type: html.ElementNode, Data: tok.Data, DataAtom: tok.DataAtom, Attr: tok.Attr}
+			for _, p := range parts {
+				switch {
+				case p.done:
+				case p.tag == "":
+					if p.matcher.Match(n) {
+						p.tag, p.depth = tok.Data, 1
+					}
+				default:
+					if tok.Data == p.tag {
+						p.depth++
+					}
+					if p.needsItem && detailAttachmentMatcher.Match(n) {
+						p.hasItem = true
+					}
+				}
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			for _, p := range parts {
+				if p.done || p.tag != string(name) {
+					continue
+				}
+				if p.depth--; p.depth > 0 {
+					continue
+				}
+				if p.needsItem && !p.hasItem {
+					// An empty list; look for another.
+					p.tag = ""
+					continue
+				}
+				p.done = true
+			}
+		}
+		if !slices.ContainsFunc(parts, func(p *detailPart) bool { return !p.done }) {
+			return buf.Bytes(), nil
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Values of Project.Status in --diff mode.
+const (
+	statusAdded   = "added"
+	statusChanged = "changed"
+	statusRemoved = "removed"
+)
+
+// diffProjects compares a fresh scrape against a baseline by project key and
+// returns only the differences: added and changed projects in fresh order,
+// then removed ones in baseline order, each marked with its Status.
+func diffProjects(baseline, fresh []Project) []Project {
+	old := make(map[string]Project, len(baseline))
+	for _, p := range baseline {
+		old[projectKey(p)] = p
+	}
+
+	var diff []Project
+	current := make(map[string]bool, len(fresh))
+	for _, p := range fresh {
+		key := projectKey(p)
+		current[key] = true
+		prev, ok := old[key]
+		if !ok {
+			p.Status = statusAdded
+			diff = append(diff, p)
+			continue
+		}
+		if changes := projectChanges(prev, p); len(changes) > 0 {
+			p.Status = statusChanged
+			p.Changes = changes
+			diff = append(diff, p)
+		}
+	}
+	for _, p := range baseline {
+		if !current[projectKey(p)] {
+			p.Status = statusRemoved
+			p.Changes = nil
+			diff = append(diff, p)
+		}
+	}
+	return diff
+}
+
+// projectChanges describes how the fields bidders care about moved between
+// two snapshots of the same project. Time left is ignored as it always moves.
+func projectChanges(prev, cur Project) []string {
+	var changes []string
+	compare := func(field, a, b string) {
+		if a != b {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", field, a, b))
+		}
+	}
+	compare("title", prev.Title, cur.Title)
+	compare("budget", prev.Budget, cur.Budget)
+	compare("average_bid", prev.AverageBid, cur.AverageBid)
+	// Counts are compared as numbers: the text differs between files
+	// written with and without --bids-count-text for the same count.
+	compare("bids_count_num", bidsCountString(prev), bidsCountString(cur))
+	compare("description", prev.Description, cur.Description)
+	return changes
+}
+
+// bidsCountString renders p's bid count for projectChanges, "" when unknown.
+func bidsCountString(p Project) string {
+	if p.BidsCountNum == nil {
+		return ""
+	}
+	return strconv.Itoa(*p.BidsCountNum)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxFilenameBytes keeps generated names well inside the 255-byte limit of
+// common filesystems, leaving room for a directory-level suffix.
+const maxFilenameBytes = 200
+
+// windowsReserved are device names Windows refuses as a file's base name,
+// whatever the extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeFilename turns a name the program derives, such as the default
+// timestamped output name or the extension taken from a template's file
+// name, into a single path element that's safe on Linux, macOS and Windows.
+// Separators, reserved punctuation and control characters become '_',
+// trailing dots and spaces are dropped, reserved device names are prefixed,
+// and overly long names are truncated on a rune boundary while keeping the
+// extension. Paths the user gives, like -O, are used as they are.
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(strings.TrimSpace(name), ". ")
+	if name == "" {
+		return "_"
+	}
+
+	// Windows reserves the device names whatever follows the first dot, so
+	// "CON.tar.gz" is as unusable as "CON".
+	stem, _, _ := strings.Cut(name, ".")
+	if windowsReserved[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		name = "_" + name
+	}
+
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if len(ext) > maxFilenameBytes/2 {
+		ext = ""
+		base = name
+	}
+	return truncateBytes(base, maxFilenameBytes-len(ext)) + ext
+}
+
+// truncateBytes cuts s to at most n bytes without splitting a rune.
+func truncateBytes(s string, n int) string {
+	for len(s) > n {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+	return s
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"results.json", "results.json"},
+		{"a/b\\c:d*e?f\"g<h>i|j", "a_b_c_d_e_f_g_h_i_j"},
+		{"tab\tand\nnewline", "tab_and_newline"},
+		{"trailing dots...", "trailing dots"},
+		{"  spaced  ", "spaced"},
+		{"", "_"},
+		{"...", "_"},
+		// Windows-reserved device names, with any extension.
+		{"CON", "_CON"},
+		{"con.txt", "_con.txt"},
+		{"CON.tar.gz", "_CON.tar.gz"},
+		{"Nul.", "_Nul"},
+		{"aux .csv", "_aux .csv"},
+		{"LPT9.json", "_LPT9.json"},
+		{"COM1.backup.md", "_COM1.backup.md"},
+		{"CONSOLE.txt", "CONSOLE.txt"},
+		{"COM10", "COM10"},
+		{"icon.png", "icon.png"},
+		// Unicode is kept as it is.
+		{"日本語のプロジェクト.csv", "日本語のプロジェクト.csv"},
+		{"café: menu/design?", "café_ menu_design_"},
+		{"Ünïcödé ✓ 🚀.md", "Ünïcödé ✓ 🚀.md"},
+	}
+	for _, tt := range tests {
+		if got := sanitizeFilename(tt.in); got != tt.want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFilenameTruncates(t *testing.T) {
+	for _, in := range []string{
+		strings.Repeat("a", 300) + ".json",
+		strings.Repeat("日本", 100) + ".json",
+		strings.Repeat("🚀", 100) + ".json",
+	} {
+		got := sanitizeFilename(in)
+		if len(got) > maxFilenameBytes || !utf8.ValidString(got) || !strings.HasSuffix(got, ".json") {
+			t.Errorf("sanitizeFilename(%.20q...) = %q (%d bytes), want at most %d valid bytes ending in .json", in, got, len(got), maxFilenameBytes)
+		}
+	}
+}
+
+func TestHandleOutputSanitizesTemplateName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the template's own name isn't a valid file name here")
+	}
+	resetFlags(t)
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "digest.a:b?.tmpl")
+	if err := os.WriteFile(tmpl, []byte("{{len .Projects}} projects\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	setFlags(t, [][2]string{{"template", tmpl}, {"output-dir", out}})
+	if !handleOutput(OutputData{Projects: []Project{{Title: "One"}}}) {
+		t.Fatal("handleOutput failed")
+	}
+	entries, err := os.ReadDir(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || !strings.HasPrefix(entries[0].Name(), outputPrefix) || !strings.HasSuffix(entries[0].Name(), ".a_b_") {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("wrote %q, want one timestamped file ending in .a_b_", names)
+	}
+}
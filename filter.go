@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// filterProjects applies the post-scrape filters selected on the command
+// line, preserving the order of the projects it keeps.
+func filterProjects(projects []Project) []Project {
+	var kept []Project
+	for _, p := range projects {
+		if !includeClosed && (p.Closed || isClosedTimeLeft(p.TimeLeft)) {
+			continue
+		}
+		if onlyHourly && p.PriceType != priceTypeHourly {
+			continue
+		}
+		if onlyFixed && p.PriceType != priceTypeFixed {
+			continue
+		}
+		if onlyRemote && !p.Remote {
+			continue
+		}
+		if locationFilter != "" && !strings.Contains(strings.ToLower(p.Location), strings.ToLower(locationFilter)) {
+			continue
+		}
+		if minRating > 0 && p.EmployerRating < minRating {
+			continue
+		}
+		if minReviews > 0 && p.EmployerReviews < minReviews {
+			continue
+		}
+		if p.EmployerCountry != "" && slices.ContainsFunc(excludeCountries, func(c string) bool {
+			return strings.EqualFold(strings.TrimSpace(c), p.EmployerCountry)
+		}) {
+			continue
+		}
+		if len(currencies) > 0 && !slices.ContainsFunc(currencies, func(c string) bool {
+			return strings.EqualFold(strings.TrimSpace(c), p.Currency)
+		}) {
+			continue
+		}
+		if (minAvgBid > 0 || maxAvgBid > 0) && p.AverageBidAmount <= 0 {
+			continue
+		}
+		if minAvgBid > 0 && p.AverageBidAmount < minAvgBid {
+			continue
+		}
+		if maxAvgBid > 0 && p.AverageBidAmount > maxAvgBid {
+			continue
+		}
+		if minValue > 0 && p.ValueScore < minValue {
+			continue
+		}
+		if postedWithin > 0 && (p.PostedAt.IsZero() || time.Since(p.PostedAt) > postedWithin) {
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// limitPerCountry keeps at most n projects per employer country, in their
+// current order, with projects of unknown country sharing an "unknown"
+// bucket. It returns the kept projects and how many each country lost.
+func limitPerCountry(projects []Project, n int) ([]Project, map[string]int) {
+	counts := make(map[string]int)
+	trimmed := make(map[string]int)
+	var kept []Project
+	for _, p := range projects {
+		country := p.EmployerCountry
+		if country == "" {
+			country = "unknown"
+		}
+		if counts[country] >= n {
+			trimmed[country]++
+			continue
+		}
+		counts[country]++
+		kept = append(kept, p)
+	}
+	return kept, trimmed
+}
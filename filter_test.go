@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestExcludeCountriesAfterInclude(t *testing.T) {
+	resetFlags(t)
+	setFlags(t, [][2]string{{"clientCountries", "us,gb"}, {"exclude-countries", "GB"}})
+
+	// The include list goes to Freelancer as is; the exclusions can't.
+	link, _ := buildURL("", 1)
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := u.Query().Get("clientCountries"); got != "us,gb" {
+		t.Errorf("search URL clientCountries = %q, want us,gb", got)
+	}
+
+	projects := []Project{
+		{Title: "Included", EmployerCountry: "us"},
+		{Title: "Included and excluded", EmployerCountry: "gb"},
+		{Title: "Unknown country"},
+	}
+	got := titles(filterProjects(projects))
+	if want := []string{"Included", "Unknown country"}; !slices.Equal(got, want) {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+}
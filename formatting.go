@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// blockTags are the elements formattedText starts a new line for.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "ul": true, "ol": true,
+	"pre": true, "blockquote": true, "h1": true, "h2": true, "h3": true,
+	"h4": true, "h5": true, "h6": true, "tr": true,
+}
+
+// descriptionFromNode returns a description's text: collapsed onto one line
+// by cleanText, or with --preserve-formatting, by formattedText.
+func descriptionFromNode(s *goquery.Selection) string {
+	if preserveFormatting && s.Length() > 0 {
+		return formattedText(s.Get(0))
+	}
+	return cleanText(s.Text())
+}
+
+// formattedText returns the text of n keeping its line structure: line
+// breaks in the text and block elements start new lines, list items are
+// bulleted with "- ", runs of spaces within a line are collapsed, and blank
+// lines are dropped.
+func formattedText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			sb.WriteString(n.Data)
+			return
+		case html.ElementNode:
+		default:
+			return
+		}
+		tag := strings.ToLower(n.Data)
+		if droppedTags[tag] {
+			return
+		}
+		if blockTags[tag] {
+			sb.WriteByte('\n')
+		}
+		if tag == "li" {
+			sb.WriteString("- ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if blockTags[tag] {
+			sb.WriteByte('\n')
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walk(c)
+	}
+
+	return tidyLines(sb.String())
+}
+
+// tidyLines collapses the spaces within each line of s and drops blank
+// lines, keeping the line breaks between the rest.
+func tidyLines(s string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r", ""), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
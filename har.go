@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// harRecorder is an http.RoundTripper that records every exchange for
+// --har-file, so a scrape that goes wrong can be inspected or attached to a
+// bug report. Entries are kept in memory and written by save.
+type harRecorder struct {
+	next    http.RoundTripper
+	path    string
+	redact  bool
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+// harRedactedHeaders are replaced with "REDACTED" unless --har-unredacted
+// is set.
+var harRedactedHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+}
+
+// The HAR 1.2 structures, limited to what's recorded.
+type (
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		Cookies     []harNameValue `json:"cookies"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Headers     []harNameValue `json:"headers"`
+		Cookies     []harNameValue `json:"cookies"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+	harEntry struct {
+		StartedDateTime time.Time   `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		Comment         string      `json:"comment,omitempty"`
+	}
+)
+
+// newHARRecorder wraps next, recording into path.
+func newHARRecorder(next http.RoundTripper, path string, redact bool) *harRecorder {
+	return &harRecorder{next: next, path: path, redact: redact}
+}
+
+// RoundTrip performs the request and records it along with the response,
+// whose body is buffered so it can be both recorded and read by the caller.
+// Failed requests are recorded with status 0 and the error as a comment.
+func (h *harRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	entry := harEntry{StartedDateTime: start}
+	entry.Request = harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Headers:     h.headers(req.Header),
+		QueryString: []harNameValue{},
+		Cookies:     []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    0,
+	}
+	for name, values := range req.URL.Query() {
+		for _, v := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{name, v})
+		}
+	}
+
+	resp, err := h.next.RoundTrip(req)
+	waited := time.Since(start)
+	if err != nil {
+		entry.Comment = err.Error()
+		entry.Response = harResponse{Headers: []harNameValue{}, Cookies: []harNameValue{}, HeadersSize: -1, BodySize: -1}
+		entry.Time = ms(waited)
+		entry.Timings = harTimings{Wait: ms(waited)}
+		h.add(entry)
+		return nil, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if readErr != nil {
+		entry.Comment = readErr.Error()
+	}
+	total := time.Since(start)
+
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Headers:     h.headers(resp.Header),
+		Cookies:     []harNameValue{},
+		Content: harContent{
+			Size:     len(body),
+			MimeType: resp.Header.Get("Content-Type"),
+			Text:     string(body),
+		},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	entry.Time = ms(total)
+	entry.Timings = harTimings{Wait: ms(waited), Receive: ms(total - waited)}
+	h.add(entry)
+	if readErr != nil {
+		return nil, readErr
+	}
+	return resp, nil
+}
+
+func (h *harRecorder) add(e harEntry) {
+	h.mu.Lock()
+	h.entries = append(h.entries, e)
+	h.mu.Unlock()
+}
+
+// headers converts hdr to HAR name/value pairs, redacting credentials.
+func (h *harRecorder) headers(hdr http.Header) []harNameValue {
+	out := []harNameValue{}
+	for name, values := range hdr {
+		for _, v := range values {
+			if h.redact && harRedactedHeaders[strings.ToLower(name)] {
+				v = "REDACTED"
+			}
+			out = append(out, harNameValue{name, v})
+		}
+	}
+	return out
+}
+
+// save writes the recorded exchanges as a HAR 1.2 file. It's safe to call on
+// a nil recorder.
+func (h *harRecorder) save() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	doc := map[string]any{
+		"log": map[string]any{
+			"version": "1.2",
+			"creator": map[string]string{"name": "flparser", "version": versionString()},
+			"entries": append([]harEntry{}, h.entries...),
+		},
+	}
+	f, err := os.Create(h.path)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ms converts d to the fractional milliseconds HAR uses.
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// saveHAR writes harLog once if --har-file is set, logging rather than
+// failing on error since the scrape itself may still have succeeded.
+func saveHAR() {
+	if err := harLog.save(); err != nil {
+		log.Printf("Warning: could not write HAR file: %v", err)
+	} else if harLog != nil {
+		fmt.Println("Recorded HTTP traffic to", harLog.path)
+		harLog = nil
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// loadInputGlob merges the projects from every JSON output file matching
+// pattern, oldest file first. A project seen in several files keeps the
+// position of its first appearance and the data of its latest.
+func loadInputGlob(pattern string) (OutputData, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return OutputData{}, err
+	}
+	if len(paths) == 0 {
+		return OutputData{}, fmt.Errorf("no files match %s", pattern)
+	}
+
+	type input struct {
+		path    string
+		modTime int64
+	}
+	inputs := make([]input, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return OutputData{}, err
+		}
+		inputs = append(inputs, input{path, info.ModTime().UnixNano()})
+	}
+	sort.SliceStable(inputs, func(i, j int) bool { return inputs[i].modTime < inputs[j].modTime })
+
+	var projects []Project
+	position := make(map[string]int)
+	for _, in := range inputs {
+		fileProjects, err := readOutputFile(in.path)
+		if err != nil {
+			return OutputData{}, fmt.Errorf("%s: %w", in.path, err)
+		}
+		for _, p := range fileProjects {
+			p.Index = 0
+			key := projectKey(p)
+			if i, ok := position[key]; ok {
+				projects[i] = p
+				continue
+			}
+			position[key] = len(projects)
+			projects = append(projects, p)
+		}
+	}
+
+	return OutputData{
+		Parameters: map[string]string{
+			"input_glob":  pattern,
+			"input_files": strconv.Itoa(len(inputs)),
+		},
+		Projects: projects,
+	}, nil
+}
+
+// readOutputFile reads the projects from a JSON file written by any schema
+// version of this tool. Unknown fields are ignored, and files that only have
+// grouped projects are flattened. Gzipped (.gz) files are decompressed.
+func readOutputFile(path string) ([]Project, error) {
+	raw, err := readOutputBytes(path)
+	if err != nil {
+		return nil, err
+	}
+	// --bare output is the project array on its own.
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		var projects []Project
+		if err := json.Unmarshal(trimmed, &projects); err != nil {
+			return nil, err
+		}
+		for i := range projects {
+			fillBidsCount(&projects[i])
+		}
+		return projects, nil
+	}
+	var data OutputData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, err
+	}
+	if data.SchemaVersion > schemaVersion {
+		log.Printf("Warning: %s uses schema version %d, newer than this build's %d; some fields may be lost", path, data.SchemaVersion, schemaVersion)
+	}
+	if len(data.Projects) == 0 && len(data.Groups) > 0 {
+		keys := make([]string, 0, len(data.Groups))
+		for k := range data.Groups {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		// Skill groups share projects listing several skills; each is kept
+		// once.
+		seen := make(map[string]bool)
+		for _, k := range keys {
+			for _, p := range data.Groups[k] {
+				if key := projectKey(p); !seen[key] {
+					seen[key] = true
+					data.Projects = append(data.Projects, p)
+				}
+			}
+		}
+	}
+	for i := range data.Projects {
+		fillBidsCount(&data.Projects[i])
+	}
+	return data.Projects, nil
+}
+
+// readOutputBytes returns the contents of an output file, decompressing it
+// when the name ends in .gz.
+func readOutputBytes(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		zr, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = io.ReadAll(zr); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
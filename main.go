@@ -1,19 +1,26 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"crypto/sha256"
 	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
-	"net/http"
+	"maps"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/spf13/cobra"
 )
 
@@ -23,35 +30,224 @@ const (
 )
 
 type Project struct {
-	Title       string `json:"title"`
-	Link        string `json:"link"`
-	Budget      string `json:"budget"`
-	AverageBid  string `json:"average_bid"`
-	BidsCount   string `json:"bids_count"`
-	TimeLeft    string `json:"time_left"`
-	Description string `json:"description"`
+	Index            int       `json:"index,omitempty"`
+	Title            string    `json:"title"`
+	Link             string    `json:"link"`
+	CanonicalLink    string    `json:"canonical_link,omitempty"`
+	Budget           string    `json:"budget"`
+	BudgetMin        float64   `json:"budget_min,omitempty"`
+	BudgetMax        float64   `json:"budget_max,omitempty"`
+	Currency         string    `json:"currency,omitempty"`
+	PriceType        string    `json:"price_type"`
+	AverageBid       string    `json:"average_bid"`
+	AverageBidAmount float64   `json:"average_bid_amount,omitempty"`
+	Sealed           bool      `json:"sealed,omitempty"`
+	BidsCount        string    `json:"bids_count,omitempty"`
+	BidsCountNum     *int      `json:"bids_count_num,omitempty"`
+	Entries          int       `json:"entries,omitempty"`
+	ValueScore       float64   `json:"value_score,omitempty"`
+	HasEmployerInfo  bool      `json:"has_employer_info,omitempty"`
+	EmployerRating   float64   `json:"employer_rating,omitempty"`
+	EmployerReviews  int       `json:"employer_reviews,omitempty"`
+	EmployerCountry  string    `json:"employer_country,omitempty"`
+	Location         string    `json:"location,omitempty"`
+	Remote           bool      `json:"remote,omitempty"`
+	TimeLeft         string    `json:"time_left"`
+	Closed           bool      `json:"closed,omitempty"`
+	PostedAt         time.Time `json:"posted_at,omitzero"`
+	FirstSeen        time.Time `json:"first_seen,omitzero"`
+	LastSeen         time.Time `json:"last_seen,omitzero"`
+	Description      string    `json:"description"`
+	Summary          string    `json:"summary,omitempty"`
+	DescriptionHTML  string    `json:"description_html,omitempty"`
+	Skills           []string  `json:"skills,omitempty"`
+	Attachments      int       `json:"attachments,omitempty"`
+	SkillMatch       int       `json:"skill_match,omitempty"`
+	Query            string    `json:"query,omitempty"`
+	Page             int       `json:"page,omitempty"`
+	Position         int       `json:"position,omitempty"`
+	Status           string    `json:"status,omitempty"`
+	Changes          []string  `json:"changes,omitempty"`
+}
+
+// schemaVersion identifies the shape of OutputData. Bump it whenever a field
+// is removed, renamed or changes type; purely additive fields don't need a bump.
+// schema.json describes the current version.
+//
+// Version 2 replaced the bid count text in bids_count with the number in
+// bids_count_num; --bids-count-text writes the text as well.
+const schemaVersion = 2
+
+// outputProject returns p as JSON output writes it: without the bid count
+// text unless --bids-count-text asks for it, since bids_count_num carries the
+// count as a number. Checkpoints and other internal JSON keep the text.
+func outputProject(p Project) Project {
+	if !bidsCountText {
+		p.BidsCount = ""
+	}
+	return p
+}
+
+// outputProjects applies outputProject to a copy of projects.
+func outputProjects(projects []Project) []Project {
+	out := make([]Project, len(projects))
+	for i, p := range projects {
+		out[i] = outputProject(p)
+	}
+	return out
+}
+
+// fillBidsCount makes BidsCount and BidsCountNum agree for a project read
+// back from a file, which carries one or the other depending on the schema
+// version and --bids-count-text.
+func fillBidsCount(p *Project) {
+	switch {
+	case p.BidsCountNum == nil && p.BidsCount != "":
+		p.BidsCountNum = bidsCountNum(p.BidsCount)
+	case p.BidsCountNum != nil && p.BidsCount == "":
+		p.BidsCount = bidsText(*p.BidsCountNum)
+	}
+}
+
+// bidsText renders a bid count the way project cards show it.
+func bidsText(n int) string {
+	if n == 1 {
+		return "1 bid"
+	}
+	return fmt.Sprintf("%d bids", n)
 }
 
 type OutputData struct {
-	Parameters map[string]string `json:"parameters"`
-	Projects   []Project         `json:"projects"`
+	SchemaVersion int               `json:"schema_version"`
+	Parameters    map[string]string `json:"parameters"`
+	// Search holds the search parameters with their proper types.
+	Search       *SearchParams        `json:"search,omitempty"`
+	Projects     []Project            `json:"projects"`
+	TotalResults int                  `json:"total_results,omitempty"`
+	TotalPages   int                  `json:"total_pages,omitempty"`
+	GroupBy      string               `json:"group_by,omitempty"`
+	Groups       map[string][]Project `json:"groups,omitempty"`
+	// Command is an flparser command line that reproduces the search.
+	Command string `json:"command,omitempty"`
 }
 
 var (
-	pTypes          string
-	clientCountries []string
-	fixedPriceMin   int
-	fixedPriceMax   int
-	hourlyRateMin   int
-	hourlyRateMax   int
-	skills          string
-	sortOption      string
-	queryText       string
-	pageNumber      int
-	outputFile      string
-	outputExt       string
+	pTypes             string
+	clientCountries    []string
+	fixedPriceMin      int
+	fixedPriceMax      int
+	hourlyRateMin      int
+	hourlyRateMax      int
+	skills             string
+	sortOption         string
+	queryText          string
+	pageNumber         int
+	outputFile         string
+	outputExt          string
+	groupBy            string
+	outputDir          string
+	skipUnchanged      bool
+	withIndex          bool
+	onlyHourly         bool
+	onlyFixed          bool
+	queryFile          string
+	queryWorkers       int
+	strict             bool
+	formatCurrency     bool
+	inputGlob          string
+	maxIdleConns       int
+	disableKeepAlive   bool
+	disableHTTP2       bool
+	diffBaseline       string
+	keepPartial        bool
+	minRating          float64
+	gzipOutput         bool
+	postedWithin       time.Duration
+	pagesRange         string
+	checkpointFile     string
+	resume             bool
+	useAPI             bool
+	currencies         []string
+	summaryJSON        bool
+	excludeCountries   []string
+	headN              int
+	tailN              int
+	includeSponsored   bool
+	harFile            string
+	harUnredacted      bool
+	linkBase           string
+	relativeLinks      bool
+	cookie             string
+	minAvgBid          float64
+	maxAvgBid          float64
+	bare               bool
+	locale             string
+	minReviews         int
+	onlyNew            bool
+	resetSeen          bool
+	seenFile           string
+	sortBy             string
+	minValue           float64
+	csvComments        bool
+	fullDescription    bool
+	onlyAttachments    bool
+	perCountry         int
+	failureThreshold   int
+	printCmd           bool
+	keepHTML           bool
+	flagEmoji          bool
+	mergeIntoFile      string
+	showSummary        bool
+	openLinksN         int
+	requestDelay       time.Duration
+	throttleOnBlock    bool
+	minDelay           time.Duration
+	maxDelay           time.Duration
+	descriptionText    string
+	maxPages           int
+	publishURL         string
+	publishSubject     string
+	ratesFile          string
+	manifestPath       string
+	templateFile       string
+	templateDir        string
+	templateName       string
+	preserveFormatting bool
+	cpuProfileFile     string
+	memProfileFile     string
+	preset             string
+	listPresetsFlag    bool
+	forceOverwrite     bool
+	quietEmpty         bool
+	perPage            int
+	includeClosed      bool
+	extraParams        []string
+	retryEmpty         bool
+	csvBOM             bool
+	requireSkills      []string
+	dumpOnError        string
+	dedupStore         string
+	defaultCurrency    string
+	printConfig        bool
+	onlyRemote         bool
+	locationFilter     string
+	skillsReport       string
+	jitterPercent      int
+	seed               uint64
+	searchURL          string
+	bidsCountText      bool
+	detailFields       []string
 )
 
+// harLog records HTTP exchanges for --har-file; it's nil otherwise.
+var harLog *harRecorder
+
+// projectUpgrades lists the upgrade filters exposed as --only-<name> flags,
+// in the order they're sent in the projectUpgrades query parameter.
+var projectUpgrades = []string{"featured", "recruiter", "urgent", "sealed", "nda", "guaranteed"}
+
+var onlyUpgrades = make(map[string]*bool)
+
 var rootCmd = &cobra.Command{
 	Use:   "flparser",
 	Short: "Scrape projects from Freelancer.com",
@@ -63,44 +259,493 @@ and export them to Markdown, CSV, or JSON.`,
 }
 
 func main() {
+	registerFlags()
+	rootCmd.Version = versionString()
+	rootCmd.SetVersionTemplate("flparser {{.Version}}\n")
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(pickCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().StringVar(&cleanDir, "dir", ".", "Directory to clean (usually your --output-dir)")
+	cleanCmd.Flags().DurationVar(&cleanOlderThan, "older-than", 7*24*time.Hour, "Delete files written longer ago than this, e.g. 72h")
+	cleanCmd.Flags().BoolVar(&cleanDryRun, "dry-run", false, "List the files that would be deleted without deleting them")
+
+	serveCmd.Flags().StringVar(&serveAddr, "addr", "localhost:8080", "Address to listen on")
+	serveCmd.Flags().IntVar(&serveConcurrent, "max-concurrent", 2, "Most scrapes run against Freelancer at once; further requests wait")
+	serveCmd.Flags().DurationVar(&serveCacheTTL, "cache-ttl", 5*time.Minute, "How long identical searches are answered from memory (0 disables caching)")
+	serveCmd.Flags().DurationVar(&serveTimeout, "request-timeout", 60*time.Second, "How long one request may wait for and run its scrape")
+
+	if err := rootCmd.Execute(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// registerFlags binds the root command's flags to their package-level
+// variables, setting each to its default.
+func registerFlags() {
 	rootCmd.Flags().StringVar(&pTypes, "types", "hourly,fixed", "Project types: 'hourly,fixed', 'hourly', or 'fixed'")
 	rootCmd.Flags().StringSliceVar(&clientCountries, "clientCountries", strings.Split(defaultClientCountries, ","), "Comma separated client country codes")
 
+	rootCmd.Flags().IntVar(&perCountry, "limit-per-country", 0, "Keep at most this many projects per employer country, in output order (unknown countries share one cap)")
+	rootCmd.Flags().StringSliceVar(&excludeCountries, "exclude-countries", nil, "Comma separated client country codes to drop (post-scrape, after --clientCountries)")
+
 	rootCmd.Flags().IntVar(&fixedPriceMin, "fixedMin", 0, "Minimum fixed price")
 	rootCmd.Flags().IntVar(&fixedPriceMax, "fixedMax", 0, "Maximum fixed price")
 	rootCmd.Flags().IntVar(&hourlyRateMin, "hourlyMin", 0, "Minimum hourly rate")
 	rootCmd.Flags().IntVar(&hourlyRateMax, "hourlyMax", 0, "Maximum hourly rate")
 
 	rootCmd.Flags().StringVar(&skills, "skills", defaultSkills, "Skill IDs comma separated, or 'all'")
+	rootCmd.Flags().StringSliceVar(&requireSkills, "require-skills", nil, "Keep only projects listing all of these skills, by name or ID (post-scrape)")
+	rootCmd.Flags().StringVar(&preset, "preset", "", "Search a named group of skills instead of --skills (see --list-presets)")
+	rootCmd.Flags().BoolVar(&listPresetsFlag, "list-presets", false, "List the skill presets and exit")
 	rootCmd.Flags().StringVar(&sortOption, "sort", "latest", "Sort: oldest, lowestPrice, highestPrice, fewestBids, mostBids")
+	rootCmd.Flags().StringVar(&sortBy, "sort-by", "", "Re-sort the scraped projects by: budget, budget-normalized, skillmatch, value (post-scrape)")
+	rootCmd.Flags().StringVar(&ratesFile, "rates-file", "", "JSON file of US dollar values per currency, e.g. {\"EUR\": 1.1}, overriding the built-in rates used by --sort-by budget-normalized")
+
+	for _, upgrade := range projectUpgrades {
+		onlyUpgrades[upgrade] = rootCmd.Flags().Bool("only-"+upgrade, false, fmt.Sprintf("Only %s projects (server-side)", upgrade))
+	}
+
+	rootCmd.Flags().BoolVar(&onlyHourly, "only-hourly", false, "Keep only hourly projects (post-scrape)")
+	rootCmd.Flags().BoolVar(&onlyFixed, "only-fixed", false, "Keep only fixed-price projects (post-scrape)")
+	rootCmd.Flags().BoolVar(&onlyRemote, "only-remote", false, "Keep only projects marked as remote (post-scrape)")
+	rootCmd.Flags().StringVar(&locationFilter, "location", "", "Keep only on-site projects whose location contains this text, e.g. London (post-scrape)")
+	rootCmd.MarkFlagsMutuallyExclusive("only-hourly", "only-fixed")
+
+	rootCmd.Flags().Float64Var(&minRating, "min-rating", 0, "Keep only projects whose employer rating is at least this (0-5, post-scrape)")
+
+	rootCmd.Flags().Float64Var(&minAvgBid, "min-avg-bid", 0, "Keep only projects whose average bid is at least this, in the project's currency (post-scrape)")
+	rootCmd.Flags().Float64Var(&maxAvgBid, "max-avg-bid", 0, "Keep only projects whose average bid is at most this, in the project's currency (post-scrape)")
+
+	rootCmd.Flags().Float64Var(&minValue, "min-value", 0, "Keep only fixed-price projects whose value score (budget midpoint / (bids + 1)) is at least this (post-scrape)")
+
+	rootCmd.Flags().StringSliceVar(&currencies, "currency", nil, "Keep only projects budgeted in these currency codes, e.g. USD (post-scrape)")
+
+	rootCmd.Flags().BoolVar(&onlyAttachments, "only-with-attachments", false, "Keep only projects with attached files (post-scrape; most reliable with --full-description)")
+	rootCmd.Flags().IntVar(&minReviews, "min-reviews", 0, "Keep only projects whose employer has at least this many reviews (post-scrape)")
+
+	rootCmd.Flags().DurationVar(&postedWithin, "posted-within", 0, "Keep only projects posted within this long (e.g. 6h, post-scrape)")
 
 	rootCmd.Flags().StringVar(&queryText, "q", "", "Search query text")
+	rootCmd.Flags().StringVar(&searchURL, "url", "", "Scrape a search page URL copied from freelancer.com, taking the search flags from its parameters")
+	rootCmd.Flags().StringVar(&queryFile, "query-file", "", "File with one search query per line; each is run and the results merged")
+	rootCmd.Flags().IntVar(&queryWorkers, "query-concurrency", 4, "How many --query-file searches to run at once")
 	rootCmd.Flags().IntVar(&pageNumber, "page", 1, "Page number")
+	rootCmd.Flags().StringArrayVar(&extraParams, "param", nil, "Extra search query parameter as key=value, for filters without a flag (repeatable)")
+	rootCmd.Flags().IntVar(&perPage, "per-page", 0, "Projects per request with --use-api, up to 100 (the HTML search page always shows 20)")
+	rootCmd.Flags().IntVar(&maxPages, "max-pages", 50, "Refuse to run a search that would fetch more pages than this, across all queries (0 for no limit)")
+	rootCmd.Flags().StringVar(&pagesRange, "pages", "", "Page range to scrape, e.g. 1-5 (overrides --page)")
+	rootCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "Save progress after each page to this file so an interrupted run can be resumed")
+	rootCmd.Flags().BoolVar(&resume, "resume", false, "Continue from the --checkpoint file, skipping pages already scraped")
+
+	rootCmd.Flags().BoolVar(&preserveFormatting, "preserve-formatting", false, "Keep the line breaks and list items of descriptions instead of collapsing them onto one line")
+	rootCmd.Flags().BoolVar(&keepHTML, "keep-html", false, "Also keep descriptions as sanitized HTML, preserving line breaks and links in Markdown and JSON")
+	rootCmd.Flags().StringVar(&descriptionText, "description-text", "summary", "Description shown in CSV and Markdown with --full-description: summary (the card's snippet) or full")
+	rootCmd.Flags().BoolVar(&fullDescription, "full-description", false, "Fetch each project's page for its full description and attachment count (one extra request per project)")
+	rootCmd.Flags().StringSliceVar(&detailFields, "detail-fields", nil, "Fetch only these fields from each project's page, reading it only until they're found: description, attachments (implies --full-description)")
+	rootCmd.Flags().BoolVar(&useAPI, "use-api", false, "Query Freelancer's JSON projects API instead of scraping the HTML search page")
+	rootCmd.Flags().StringVar(&cookie, "cookie", "", "Cookie header to send, e.g. copied from a browser that passed a Cloudflare challenge")
+	rootCmd.Flags().StringVar(&locale, "locale", "en", "Accept-Language sent with requests; parsing assumes English")
+	rootCmd.Flags().DurationVar(&requestDelay, "delay", 0, "Wait this long between search page requests (e.g. 2s)")
+	rootCmd.Flags().IntVar(&jitterPercent, "jitter", 0, "Randomize each gap between requests by up to this percentage of --delay either way (0-100)")
+	rootCmd.Flags().Uint64Var(&seed, "seed", 0, "Seed for --jitter's randomness, to repeat a run's timing (0 picks one at random)")
+	rootCmd.Flags().StringVar(&dumpOnError, "dump-on-error", "", "Save responses that can't be parsed as a results page in this directory")
+	rootCmd.Flags().BoolVar(&retryEmpty, "retry-empty", false, "Fetch a page once more when it has no cards and isn't a no-results page")
+	rootCmd.Flags().BoolVar(&throttleOnBlock, "throttle-on-block", false, "Adapt the delay to rate limiting: double it on every 429 or challenge page and retry, shrink it after successes")
+	rootCmd.Flags().DurationVar(&minDelay, "min-delay", 0, "Smallest delay --throttle-on-block shrinks to")
+	rootCmd.Flags().DurationVar(&maxDelay, "max-delay", time.Minute, "Largest delay --throttle-on-block grows to")
+	rootCmd.Flags().StringVar(&publishURL, "publish", "", "Publish each project as a JSON message as it's scraped, to a redis://host:port or nats://host:port URL")
+	rootCmd.Flags().StringVar(&publishSubject, "publish-subject", "flparser.projects", "Redis channel or NATS subject for --publish")
+	rootCmd.Flags().IntVar(&failureThreshold, "failure-threshold", 5, "Stop sending requests after this many consecutive failures, write what was collected and exit with an error (0 disables)")
+	rootCmd.Flags().IntVar(&maxIdleConns, "max-idle-conns", 10, "Maximum idle (keep-alive) connections kept open to Freelancer")
+	rootCmd.Flags().BoolVar(&disableKeepAlive, "disable-keepalive", false, "Open a new connection for every request")
+	rootCmd.Flags().BoolVar(&disableHTTP2, "disable-http2", false, "Use HTTP/1.1 only")
+
+	rootCmd.Flags().StringVar(&harFile, "har-file", "", "Record every HTTP request and response to this HAR file for debugging")
+	rootCmd.Flags().BoolVar(&harUnredacted, "har-unredacted", false, "Keep cookie and authorization headers in the --har-file instead of redacting them")
+
+	rootCmd.Flags().StringVar(&linkBase, "link-base", defaultLinkBase, "Base URL relative project links are resolved against")
+	rootCmd.Flags().BoolVar(&relativeLinks, "relative-links", false, "Write project links as site-relative paths instead of absolute URLs")
+	rootCmd.MarkFlagsMutuallyExclusive("link-base", "relative-links")
+
+	rootCmd.Flags().StringVar(&diffBaseline, "diff", "", "Only output projects added, removed or changed since this baseline JSON file")
+	rootCmd.Flags().StringVar(&inputGlob, "input-glob", "", "Merge previously written JSON files matching this glob instead of scraping")
 
 	rootCmd.Flags().StringVarP(&outputFile, "output", "O", "", "Output filename (e.g. results.json)")
-	rootCmd.Flags().StringVarP(&outputExt, "extension", "X", "", "Output extension if -O is not set (md, csv, json)")
+	rootCmd.Flags().StringVarP(&outputExt, "extension", "X", "", "Output extension if -O is not set (md, csv, json), or table to print to the terminal")
+	rootCmd.Flags().BoolVar(&quietEmpty, "quiet-empty", false, "Don't write any output file when no projects are left after filtering")
+	rootCmd.Flags().BoolVar(&forceOverwrite, "force", false, "Overwrite the -O file if it already exists")
+	rootCmd.Flags().BoolVar(&flagEmoji, "flag-emoji", true, "Show a flag emoji next to client countries in Markdown and table output")
+	rootCmd.Flags().StringVar(&defaultCurrency, "default-currency", "USD", "ISO code for prices that show a bare \"$\" without a currency code")
+	rootCmd.Flags().BoolVar(&formatCurrency, "format-currency", false, "Render budget amounts in CSV/Markdown with currency symbols and thousands separators")
+	rootCmd.Flags().BoolVar(&csvBOM, "csv-bom", false, "Start CSV files with a UTF-8 byte order mark so Excel reads accented characters correctly")
+	rootCmd.Flags().BoolVar(&csvComments, "csv-comments", false, "Start CSV output with '#' rows listing the search parameters (not valid RFC 4180)")
+	rootCmd.Flags().BoolVar(&bare, "bare", false, "Write JSON as a top-level project array, without the wrapper object")
+	rootCmd.Flags().BoolVar(&gzipOutput, "gzip", false, "Gzip-compress output files (adds .gz); implied by -O ending in .gz")
+	rootCmd.Flags().StringVar(&mergeIntoFile, "merge-into", "", "Merge the projects into this master JSON file, keeping first_seen and updating last_seen")
+	rootCmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory to write output files into (created if missing)")
+	rootCmd.Flags().BoolVar(&onlyNew, "only-new", false, "Output only projects no earlier --only-new run has output, and remember these")
+	rootCmd.Flags().BoolVar(&resetSeen, "reset-seen", false, "Forget the projects remembered by --only-new before running")
+	rootCmd.Flags().StringVar(&dedupStore, "dedup-store", "", "Keep --only-new's seen projects in a Redis set shared between machines (redis://[:password@]host[:port][/db][?key=set])")
+	rootCmd.Flags().StringVar(&seenFile, "seen-file", "", "File --only-new remembers projects in (default .flparser_seen in --output-dir)")
+	rootCmd.Flags().BoolVar(&skipUnchanged, "skip-unchanged", false, "Skip writing output when the projects match the previous run's")
+	rootCmd.Flags().IntVar(&headN, "head", 0, "Output only the first N projects, after filtering")
+	rootCmd.Flags().IntVar(&tailN, "tail", 0, "Output only the last N projects, after filtering (and after --head)")
+	rootCmd.Flags().BoolVar(&withIndex, "index", false, "Number projects 1..N in the output, in final order")
+	rootCmd.Flags().BoolVar(&strict, "strict", false, "Exit with an error when no project cards are found and the page isn't a no-results page")
+	rootCmd.Flags().BoolVar(&includeClosed, "include-closed", false, "Keep projects whose bidding has ended or expired (dropped by default)")
+	rootCmd.Flags().BoolVar(&includeSponsored, "include-sponsored", false, "Keep promotional cards that aren't real project listings")
+	rootCmd.Flags().BoolVar(&keepPartial, "keep-partial", false, "Keep cards missing a title or link instead of skipping them")
+	rootCmd.Flags().StringVar(&skillsReport, "skills-report", "", "Write how many output projects list each skill, most in demand first, to this file (.csv for CSV) or - for stdout")
+	rootCmd.Flags().BoolVar(&bidsCountText, "bids-count-text", false, "Also write the bid count as shown on the card (\"23 bids\") in JSON's bids_count (deprecated)")
+	rootCmd.Flags().BoolVar(&showSummary, "summary", false, "Print budget statistics, kept separate for hourly and fixed projects and per currency")
+	rootCmd.Flags().IntVar(&openLinksN, "open-links", 0, "Open the first N project links in the default browser after writing the output (asks above 10, capped at 50)")
+	rootCmd.Flags().StringVar(&templateFile, "template", "", "Render the output with this Go text/template file instead of the built-in formats")
+	rootCmd.Flags().StringVar(&templateDir, "output-template-dir", "", "Directory of named templates (NAME.tmpl, or NAME.EXT.tmpl) for --template-name")
+	rootCmd.Flags().StringVar(&templateName, "template-name", "", "Render the output with the named template from --output-template-dir")
+	rootCmd.Flags().StringVar(&manifestPath, "manifest", "", "Write a JSON manifest of every file this run generated, with sizes, checksums and the search parameters")
+	rootCmd.Flags().StringVar(&cpuProfileFile, "cpuprofile", "", "Write a pprof CPU profile of the run to this file")
+	rootCmd.Flags().StringVar(&memProfileFile, "memprofile", "", "Write a pprof heap profile at the end of the run to this file")
+	rootCmd.Flags().BoolVar(&printConfig, "print-effective-config", false, "Print every setting in effect, after applying --preset, as JSON and exit without scraping")
+	rootCmd.Flags().BoolVar(&printCmd, "print-cmd", false, "Print the flparser command line that reproduces this search")
+	rootCmd.Flags().BoolVar(&summaryJSON, "summary-json", false, "Print a one-line JSON summary of the run to stderr when it ends")
+	rootCmd.Flags().StringVar(&groupBy, "group-by", "", "Group projects in Markdown/JSON output by: type, currency, status, skill, country")
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	commandFlags = rootCmd.Flags()
 }
 
 func runScraper() {
-	// 1. Build URL
-	targetURL, paramsMap := buildURL()
-	fmt.Print("Fetching Freelancer.com...\n")
+	if listPresetsFlag {
+		if err := listPresets(os.Stdout, newHTTPClient()); err != nil {
+			fatalf("Error: %v", err)
+		}
+		return
+	}
+	if searchURL != "" {
+		if queryFile != "" || inputGlob != "" || preset != "" {
+			fatalf("Error: --url can't be combined with --query-file, --input-glob or --preset")
+		}
+		if err := applySearchURL(searchURL); err != nil {
+			fatalf("Error: %v", err)
+		}
+	}
+	startProfiling()
+	defer stopProfiling()
 
-	projects, err := scrapeFreelancer(targetURL)
-	if err != nil {
-		log.Fatalf("Error scraping: %v", err)
+	if groupBy != "" && groupKeyFuncs[groupBy] == nil {
+		fatalf("Unknown --group-by value: %s (expected type, currency, status, skill or country)", groupBy)
+	}
+	if _, err := templatePath(); err != nil {
+		fatalf("Error: %v", err)
+	}
+	for _, kv := range extraParams {
+		if key, _, ok := strings.Cut(kv, "="); !ok || key == "" {
+			fatalf("Error: --param %q must be key=value", kv)
+		}
+	}
+	if perPage < 0 || perPage > maxAPIPageSize {
+		fatalf("Error: --per-page must be between 1 and %d", maxAPIPageSize)
+	}
+	if perPage > 0 && !useAPI {
+		log.Printf("Warning: --per-page only applies with --use-api; the HTML search page always shows %d projects", resultsPerPage)
+	}
+	defaultCurrency = strings.ToUpper(defaultCurrency)
+	if !currencyPattern.MatchString(defaultCurrency) || len(defaultCurrency) != 3 {
+		fatalf("Error: --default-currency must be a three-letter ISO code such as USD, got %q", defaultCurrency)
+	}
+	if mergeIntoFile != "" && isStream(mergeIntoFile) {
+		fatalf("Error: --merge-into needs a regular file; %s is a pipe or device", mergeIntoFile)
+	}
+	if jitterPercent < 0 || jitterPercent > 100 {
+		fatalf("Error: --jitter must be a percentage between 0 and 100")
+	}
+	if jitterPercent > 0 && requestDelay == 0 && !throttleOnBlock {
+		log.Printf("Warning: --jitter has no effect without --delay")
+	}
+	for _, field := range detailFields {
+		if !slices.Contains(detailFieldNames, field) {
+			fatalf("Unknown --detail-fields value: %s (expected %s)", field, strings.Join(detailFieldNames, ", "))
+		}
+	}
+	if len(detailFields) > 0 {
+		fullDescription = true
+	}
+	if descriptionText != "summary" && descriptionText != "full" {
+		fatalf("Unknown --description-text value: %s (expected summary or full)", descriptionText)
+	}
+	if sortBy != "" && sortByFuncs[sortBy] == nil {
+		fatalf("Unknown --sort-by value: %s (expected %s)", sortBy, sortByNames())
+	}
+
+	if ratesFile != "" {
+		if err := loadRates(ratesFile); err != nil {
+			fatalf("Error reading rates file: %v", err)
+		}
 	}
-	fmt.Printf("Found %d projects.\n", len(projects))
 
-	handleOutput(projects, paramsMap)
+	var baseline []Project
+	if diffBaseline != "" {
+		var err error
+		baseline, err = readOutputFile(diffBaseline)
+		if err != nil {
+			fatalf("Error reading diff baseline: %v", err)
+		}
+		if groupBy == "" {
+			groupBy = "status"
+		}
+	}
+
+	if resetSeen {
+		where, err := resetSeenStore()
+		if err != nil {
+			fatalf("Error resetting seen projects: %v", err)
+		}
+		fmt.Println("Cleared seen projects in", where)
+	}
+	if onlyNew {
+		var err error
+		seenProjects, err = openSeenStore()
+		if err != nil {
+			fatalf("Error reading seen projects: %v", err)
+		}
+	}
+
+	client := newHTTPClient()
+	if preset != "" {
+		if commandFlags != nil && commandFlags.Changed("skills") {
+			fatalf("Error: use either --preset or --skills, not both")
+		}
+		if err := applyPreset(client, preset); err != nil {
+			fatalf("Error: %v", err)
+		}
+	}
+	if printConfig {
+		if err := printEffectiveConfig(os.Stdout, commandFlags); err != nil {
+			fatalf("Error printing configuration: %v", err)
+		}
+		return
+	}
+	var data OutputData
+	// While scraping and fetching details, the first Ctrl-C stops the
+	// requests and the projects collected so far are still written.
+	ctx, stop := context.Background(), func() {}
+	if inputGlob != "" {
+		var err error
+		data, err = loadInputGlob(inputGlob)
+		if err != nil {
+			fatalf("Error reading input files: %v", err)
+		}
+		fmt.Printf("Loaded %d unique projects from %s files.\n", len(data.Projects), data.Parameters["input_files"])
+	} else {
+		queries := []string{queryText}
+		if queryFile != "" {
+			var err error
+			queries, err = readQueryFile(queryFile)
+			if err != nil {
+				fatalf("Error reading query file: %v", err)
+			}
+		}
+
+		pages, err := pageList()
+		if err != nil {
+			fatalf("Error: %v", err)
+		}
+		// A typo such as --pages 1-5000 shouldn't turn into thousands of
+		// requests; going past the cap has to be asked for.
+		if n := len(queries) * len(pages); maxPages > 0 && n > maxPages {
+			fatalf("Error: this run would fetch %d pages, more than --max-pages %d; raise --max-pages (or set it to 0) if that's intended", n, maxPages)
+		}
+		if resume && checkpointFile == "" {
+			fatalf("Error: --resume needs --checkpoint to say which file to resume from")
+		}
+		cp, err := openCheckpoint(checkpointFile, searchSignature(queries, pages), resume)
+		if err != nil {
+			fatalf("Error opening checkpoint: %v", err)
+		}
+
+		if publishURL != "" {
+			publisher, err = openPublisher(publishURL, publishSubject)
+			if err != nil {
+				fatalf("Error connecting to --publish: %v", err)
+			}
+		}
+
+		fmt.Print("Fetching Freelancer.com...\n")
+
+		ctx, stop = interruptContext()
+		results := scrapeQueries(ctx, client, queries, pages, cp)
+		publisher.close()
+		cp.finish(results)
+		data = mergeQueryResults(results)
+		scoreSkillMatch(client, data.Projects)
+		fmt.Printf("Found %d projects.\n", len(data.Projects))
+	}
+	if data.TotalResults > 0 {
+		fmt.Printf("Search has %d results across %d pages.\n", data.TotalResults, data.TotalPages)
+	}
+	// Details take a request per project, so they're fetched for what the
+	// cheaper filters keep; the attachment filter needs them and runs after.
+	scraped := len(data.Projects)
+	data.Projects = filterProjects(data.Projects)
+	if len(requireSkills) > 0 {
+		var err error
+		if data.Projects, err = requireAllSkills(client, data.Projects, requireSkills); err != nil {
+			fatalf("Error applying --require-skills: %v", err)
+		}
+		data.Parameters["require_skills"] = strings.Join(requireSkills, ",")
+	}
+	if fullDescription && inputGlob == "" && len(data.Projects) > 0 {
+		fmt.Printf("Fetching details for %d projects...\n", len(data.Projects))
+		fetchDetails(ctx, client, data.Projects)
+		data.Parameters["full_description"] = "true"
+		if len(detailFields) > 0 {
+			data.Parameters["detail_fields"] = strings.Join(detailFields, ",")
+		}
+		if ctx.Err() != nil && data.Parameters["partial"] == "" {
+			data.Parameters["partial"] = "interrupted"
+			summary.Status = statusPartial
+		}
+	}
+	stop()
+	if onlyAttachments {
+		data.Projects = slices.DeleteFunc(data.Projects, func(p Project) bool { return p.Attachments == 0 })
+	}
+	summary.FilteredOut = scraped - len(data.Projects)
+
+	if seenProjects != nil {
+		before := len(data.Projects)
+		var err error
+		if data.Projects, err = seenProjects.filterNew(data.Projects); err != nil {
+			fatalf("Error checking seen projects: %v", err)
+		}
+		data.Parameters["only_new"] = "true"
+		fmt.Printf("%d new projects (%d seen before).\n", len(data.Projects), before-len(data.Projects))
+	}
+
+	if diffBaseline != "" {
+		data.Projects = diffProjects(baseline, data.Projects)
+		data.Parameters["diff_baseline"] = diffBaseline
+		fmt.Printf("Diff against %s: %d projects added, changed or removed.\n", diffBaseline, len(data.Projects))
+	}
+
+	if sortBy != "" {
+		sortProjects(data.Projects)
+		data.Parameters["sort_by"] = sortBy
+	}
+
+	if perCountry > 0 {
+		var trimmed map[string]int
+		data.Projects, trimmed = limitPerCountry(data.Projects, perCountry)
+		data.Parameters["limitPerCountry"] = strconv.Itoa(perCountry)
+		countries := slices.Sorted(maps.Keys(trimmed))
+		for _, c := range countries {
+			fmt.Printf("Trimmed %d projects from %s (limit %d per country).\n", trimmed[c], c, perCountry)
+		}
+	}
+
+	// Like piping through head and then tail: --head trims first, then --tail
+	// takes the end of what's left.
+	if headN > 0 && len(data.Projects) > headN {
+		data.Projects = data.Projects[:headN]
+		data.Parameters["head"] = strconv.Itoa(headN)
+	}
+	if tailN > 0 && len(data.Projects) > tailN {
+		data.Projects = data.Projects[len(data.Projects)-tailN:]
+		data.Parameters["tail"] = strconv.Itoa(tailN)
+	}
+
+	if withIndex {
+		for i := range data.Projects {
+			data.Projects[i].Index = i + 1
+		}
+	}
+
+	if showSummary {
+		writeBudgetSummary(os.Stdout, data.Projects)
+	}
+	if skillsReport != "" {
+		if err := writeSkillsReport(skillsReport, data.Projects); err != nil {
+			fatalf("Error writing skills report: %v", err)
+		}
+	}
+
+	if data.Parameters["input_glob"] == "" {
+		data.Search = typedParams(data.Parameters)
+	}
+	data.Command = reproduceCommand(commandFlags)
+	if printCmd {
+		fmt.Println("Command:", data.Command)
+	}
+
+	saveHAR()
+	if mergeIntoFile != "" {
+		added, updated, err := mergeInto(mergeIntoFile, data.Projects, data.Parameters, time.Now().UTC().Truncate(time.Second))
+		if err != nil {
+			fatalf("Error merging into %s: %v", mergeIntoFile, err)
+		}
+		fmt.Printf("Merged into %s: %d new, %d updated.\n", mergeIntoFile, added, updated)
+		recordGenerated(mergeIntoFile, "json")
+	}
+	// With --merge-into the master file is the output, unless a format was
+	// asked for as well.
+	written := true
+	if mergeIntoFile == "" || outputFile != "" || outputExt != "" {
+		written = handleOutput(data)
+	}
+	if manifestPath != "" {
+		writeManifest(manifestPath, data)
+	}
+	if openLinksN > 0 {
+		openLinks(data.Projects, openLinksN, os.Stdin, os.Stdout)
+	}
+	// Projects whose output failed aren't remembered, so the next run
+	// outputs them again.
+	if written {
+		if err := recordSeen(data.Projects); err != nil {
+			log.Printf("Warning: could not record seen projects: %v", err)
+		}
+	} else {
+		releaseSeen()
+	}
+	summary.Projects = len(data.Projects)
+	if circuit.isOpen() {
+		fatalf("Error: circuit opened due to repeated failures; Freelancer may be throttling or blocking requests, so wait before running again")
+	}
+	writeSummary()
+}
+
+// priceFlagSet reports whether a price flag should be sent: when it's
+// positive, or was given explicitly as 0, so "--fixedMin 0" can be told
+// apart from leaving the flag out.
+func priceFlagSet(name string, v int) bool {
+	return v > 0 || (v == 0 && commandFlags != nil && commandFlags.Changed(name))
 }
 
-func buildURL() (string, map[string]string) {
+// priceText returns the price shown for p: its budget, or its average bid
+// when the card only showed that.
+func priceText(p Project) string {
+	if p.Budget == "" && p.AverageBid != "" {
+		return p.AverageBid + " avg bid"
+	}
+	return p.Budget
+}
+
+// shownDescription returns the description CSV and Markdown show: the
+// card's snippet when --full-description kept one, unless --description-text
+// asks for the full text.
+func shownDescription(p Project) string {
+	if p.Summary != "" && descriptionText != "full" {
+		return p.Summary
+	}
+	return p.Description
+}
+
+// buildURL returns the search URL for the current flags with the given
+// query text and page, along with a record of the parameters it set.
+func buildURL(query string, page int) (string, map[string]string) {
 	baseURL := "https://www.freelancer.com/search/projects"
 	u, _ := url.Parse(baseURL)
 	q := u.Query()
@@ -119,19 +764,25 @@ func buildURL() (string, map[string]string) {
 		paramsRecord["clientCountries"] = val
 	}
 
-	if fixedPriceMin > 0 {
+	// Freelancer can only include countries, so exclusions are recorded here
+	// and applied by filterProjects to whatever the inclusion list let through.
+	if len(excludeCountries) > 0 {
+		paramsRecord["excludeCountries"] = strings.ToLower(strings.Join(excludeCountries, ","))
+	}
+
+	if priceFlagSet("fixedMin", fixedPriceMin) {
 		q.Set("projectFixedPriceMin", strconv.Itoa(fixedPriceMin))
 		paramsRecord["projectFixedPriceMin"] = strconv.Itoa(fixedPriceMin)
 	}
-	if fixedPriceMax > 0 {
+	if priceFlagSet("fixedMax", fixedPriceMax) {
 		q.Set("projectFixedPriceMax", strconv.Itoa(fixedPriceMax))
 		paramsRecord["projectFixedPriceMax"] = strconv.Itoa(fixedPriceMax)
 	}
-	if hourlyRateMin > 0 {
+	if priceFlagSet("hourlyMin", hourlyRateMin) {
 		q.Set("projectHourlyRateMin", strconv.Itoa(hourlyRateMin))
 		paramsRecord["projectHourlyRateMin"] = strconv.Itoa(hourlyRateMin)
 	}
-	if hourlyRateMax > 0 {
+	if priceFlagSet("hourlyMax", hourlyRateMax) {
 		q.Set("projectHourlyRateMax", strconv.Itoa(hourlyRateMax))
 		paramsRecord["projectHourlyRateMax"] = strconv.Itoa(hourlyRateMax)
 	}
@@ -143,204 +794,408 @@ func buildURL() (string, map[string]string) {
 		paramsRecord["projectSkills"] = "all"
 	}
 
-	if sortOption != "" && sortOption != "latest" {
-		q.Set("projectSort", sortOption)
-		paramsRecord["projectSort"] = sortOption
+	var upgrades []string
+	for _, upgrade := range projectUpgrades {
+		if *onlyUpgrades[upgrade] {
+			upgrades = append(upgrades, upgrade)
+		}
 	}
-
-	if queryText != "" {
-		q.Set("q", queryText)
-		paramsRecord["q"] = queryText
+	if len(upgrades) > 0 {
+		val := strings.Join(upgrades, ",")
+		q.Set("projectUpgrades", val)
+		paramsRecord["projectUpgrades"] = val
 	}
 
-	if pageNumber > 1 {
-		q.Set("page", strconv.Itoa(pageNumber))
-		paramsRecord["page"] = strconv.Itoa(pageNumber)
+	// The search page has no employer-rating or review-count parameter, so
+	// these are recorded for reference and applied by filterProjects.
+	if minRating > 0 {
+		paramsRecord["minRating"] = strconv.FormatFloat(minRating, 'f', -1, 64)
 	}
-
-	u.RawQuery = q.Encode()
-	return u.String(), paramsRecord
-}
-
-func scrapeFreelancer(urlStr string) ([]Project, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-	req, err := http.NewRequest("GET", urlStr, nil)
-	if err != nil {
-		return nil, err
+	if minReviews > 0 {
+		paramsRecord["minReviews"] = strconv.Itoa(minReviews)
 	}
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
+	// Neither is there a currency parameter; the codes are matched against
+	// each card's parsed price by filterProjects.
+	if len(currencies) > 0 {
+		paramsRecord["currency"] = strings.ToUpper(strings.Join(currencies, ","))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	if sortOption != "" && sortOption != "latest" {
+		q.Set("projectSort", sortOption)
+		paramsRecord["projectSort"] = sortOption
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return nil, err
+	if query != "" {
+		q.Set("q", query)
+		paramsRecord["q"] = query
 	}
 
-	var projects []Project
-
-	cleanText := func(s string) string {
-		s = strings.ReplaceAll(s, "\n", " ")
-		s = strings.ReplaceAll(s, "\r", " ")
-		for strings.Contains(s, "  ") {
-			s = strings.ReplaceAll(s, "  ", " ")
-		}
-		return strings.TrimSpace(s)
+	// Only the API takes a page size; see buildAPIURL.
+	if perPage > 0 && useAPI {
+		paramsRecord["perPage"] = strconv.Itoa(perPage)
 	}
 
-	doc.Find(".JobSearchCard-item").Each(func(i int, s *goquery.Selection) {
-		titleNode := s.Find(".JobSearchCard-primary-heading a")
-		title := cleanText(titleNode.Text())
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+		paramsRecord["page"] = strconv.Itoa(page)
+	}
 
-		linkHref, exists := s.Find("a.JobSearchCard-ctas-btn").Attr("href")
-		if !exists {
-			linkHref, _ = titleNode.Attr("href")
+	// --param pairs fill in search parameters this tool has no flag for;
+	// anything set above wins.
+	var overridden []string
+	own := maps.Clone(q)
+	for _, kv := range extraParams {
+		key, value, _ := strings.Cut(kv, "=")
+		if own.Has(key) {
+			overridden = append(overridden, key)
+			continue
 		}
-		if strings.HasPrefix(linkHref, "/") {
-			linkHref = "https://www.freelancer.com" + linkHref
+		q.Add(key, value)
+		if prev, ok := paramsRecord[key]; ok {
+			paramsRecord[key] = prev + "," + value
+		} else {
+			paramsRecord[key] = value
 		}
+	}
+	if len(overridden) > 0 {
+		extraParamsWarning.Do(func() {
+			log.Printf("Warning: --param %s ignored; the tool's own flags set them", strings.Join(overridden, ", "))
+		})
+	}
 
-		desc := cleanText(s.Find(".JobSearchCard-primary-description").Text())
-
-		timeLeft := cleanText(s.Find(".JobSearchCard-primary-heading-days").Text())
+	u.RawQuery = q.Encode()
+	return u.String(), paramsRecord
+}
 
-		priceFull := s.Find(".JobSearchCard-secondary-price").Text()
+// extraParamsWarning makes buildURL warn about overridden --param keys once
+// rather than for every page.
+var extraParamsWarning sync.Once
 
-		budget := cleanText(priceFull)
-		budget = strings.ReplaceAll(budget, "Avg Bid", "")
-		budget = cleanText(budget)
+// handleOutput writes data in the formats the flags ask for, reporting
+// whether every file was written; the errors themselves are logged.
+func handleOutput(data OutputData) bool {
+	if quietEmpty && len(data.Projects) == 0 {
+		fmt.Println("No projects; skipping output.")
+		return true
+	}
+	baseName := sanitizeFilename(outputPrefix + time.Now().Format(outputTimeLayout))
 
-		bids := cleanText(s.Find(".JobSearchCard-secondary-entry").Text())
+	var targetFile string
+	var formats []string
 
-		avgBid := budget
+	// "-X .json", "-X JSON" and "-X json" all mean the same format.
+	extension := strings.ToLower(strings.TrimLeft(strings.TrimSpace(outputExt), "."))
+	output := outputFile
 
-		p := Project{
-			Title:       title,
-			Link:        linkHref,
-			Description: desc,
-			TimeLeft:    timeLeft,
-			Budget:      budget,
-			AverageBid:  avgBid,
-			BidsCount:   bids,
-		}
-		projects = append(projects, p)
-	})
+	// A named pipe or a device as -O is streamed to under its own name: the
+	// name says nothing about the format, and there's no file to replace.
+	streamFile := outputFile
+	if outputDir != "" && !filepath.IsAbs(streamFile) {
+		streamFile = filepath.Join(outputDir, streamFile)
+	}
+	stream := outputFile != "" && isStream(streamFile)
 
-	return projects, nil
-}
-func handleOutput(projects []Project, params map[string]string) {
-	timestamp := time.Now().Format("15-04-05_02-01-2006")
-	baseName := fmt.Sprintf("freelancer.com_%s", timestamp)
+	// A .gz suffix on -O, or --gzip, compresses every file; the format comes
+	// from the extension underneath.
+	compress := gzipOutput
+	if strings.EqualFold(filepath.Ext(output), ".gz") {
+		compress = true
+		output = output[:len(output)-len(".gz")]
+	}
 
-	var targetFile string
-	var formats []string
+	// A stream is written under its own name, so only a .gz name compresses
+	// it; --gzip can't rename it to say so.
+	if stream && gzipOutput && !strings.EqualFold(filepath.Ext(streamFile), ".gz") {
+		log.Printf("Error: --gzip can't compress %s; name it with .gz or pipe the output through gzip", streamFile)
+		return false
+	}
 
-	if outputFile != "" {
-		targetFile = outputFile
-		ext := strings.ToLower(filepath.Ext(outputFile))
+	if stream {
+		format := extension
+		if ext := strings.ToLower(filepath.Ext(output)); format == "" && ext != "" {
+			format = ext[1:]
+		}
+		formats = []string{cmp.Or(format, "csv")}
+	} else if output != "" {
+		targetFile = output
+		ext := strings.ToLower(filepath.Ext(output))
 		if ext == "" {
-			if outputExt != "" {
-				formats = []string{outputExt}
-				targetFile = outputFile + "." + outputExt
+			if extension != "" {
+				formats = []string{extension}
+				targetFile = output + "." + extension
 			} else {
 				formats = []string{"csv"}
-				targetFile = outputFile + ".csv"
+				targetFile = output + ".csv"
 			}
 		} else {
 			formats = []string{ext[1:]}
 		}
 	} else {
-		if outputExt != "" {
-			formats = []string{outputExt}
-			targetFile = fmt.Sprintf("%s.%s", baseName, outputExt)
+		if extension != "" {
+			formats = []string{extension}
+			targetFile = fmt.Sprintf("%s.%s", baseName, extension)
 		} else {
 			formats = []string{"md", "csv"}
 			targetFile = baseName
 		}
 	}
 
+	// A template replaces the built-in formats; its file name says what it
+	// renders, e.g. digest.md.tmpl.
+	tmpl, _ := templatePath()
+	if tmpl != "" {
+		formats = []string{"template"}
+		switch {
+		case stream:
+		case output == "":
+			targetFile = sanitizeFilename(fmt.Sprintf("%s.%s", baseName, templateOutputExt(tmpl)))
+		case filepath.Ext(output) == "":
+			targetFile = output + "." + sanitizeFilename(templateOutputExt(tmpl))
+		}
+	}
+
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			log.Println("Error creating output directory:", err)
+			return false
+		}
+	}
+
+	var hash string
+	hashFile := filepath.Join(outputDir, lastHashFile)
+	if skipUnchanged {
+		hash = projectsHash(data.Projects)
+		if prev, err := os.ReadFile(hashFile); err == nil && strings.TrimSpace(string(prev)) == hash {
+			fmt.Println("No change since the last run; skipping output.")
+			return true
+		}
+	}
+
+	ok := true
 	for _, fmtType := range formats {
 		fname := targetFile
 		if outputFile == "" && len(formats) > 1 {
 			fname = fmt.Sprintf("%s.%s", baseName, fmtType)
 		}
+		if outputDir != "" && !filepath.IsAbs(fname) {
+			fname = filepath.Join(outputDir, fname)
+		}
+		if compress {
+			fname += ".gz"
+		}
+		if stream {
+			fname = streamFile
+		}
+		// Timestamped names don't collide, but re-running with the same -O
+		// easily would.
+		if outputFile != "" && fmtType != "table" && !forceOverwrite && !stream {
+			if _, err := os.Stat(fname); err == nil {
+				fatalf("Error: %s already exists; pass --force to overwrite it", fname)
+			}
+		}
 
+		written := true
 		switch strings.ToLower(fmtType) {
 		case "json":
-			writeJSON(fname, projects, params)
+			written = writeJSON(fname, data)
 		case "csv":
-			writeCSV(fname, projects, params)
+			written = writeCSV(fname, data)
 		case "md":
-			writeMarkdown(fname, projects, params)
+			written = writeMarkdown(fname, data)
+		case "table":
+			writeTable(os.Stdout, data, useColor(os.Stdout))
+		case "template":
+			written = writeTemplate(fname, tmpl, data)
 		default:
 			fmt.Printf("Unknown format: %s\n", fmtType)
+			written = false
+		}
+		ok = ok && written
+	}
+
+	if skipUnchanged && ok {
+		if err := os.WriteFile(hashFile, []byte(hash+"\n"), 0644); err != nil {
+			log.Println("Error recording output hash:", err)
 		}
 	}
+	return ok
 }
 
-func writeJSON(filename string, projects []Project, params map[string]string) {
-	data := OutputData{
-		Parameters: params,
-		Projects:   projects,
+// lastHashFile records, next to the output files, the hash of the projects
+// last written so --skip-unchanged can detect identical snapshots.
+const lastHashFile = ".flparser_last_hash"
+
+// projectsHash returns a content hash of projects. TimeLeft is excluded since
+// it changes on every run even when the listings themselves don't.
+func projectsHash(projects []Project) string {
+	h := sha256.New()
+	enc := json.NewEncoder(h)
+	for _, p := range projects {
+		p.TimeLeft = ""
+		enc.Encode(p)
 	}
-	file, err := json.MarshalIndent(data, "", "  ")
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// groupKeyFuncs maps each --group-by value to the function deriving a
+// project's group keys from it. A project goes in the group of every key it
+// has, so one listing several skills appears under each of them.
+var groupKeyFuncs = map[string]func(Project) []string{
+	"type": func(p Project) []string {
+		return []string{p.PriceType}
+	},
+	"status": func(p Project) []string {
+		return []string{cmp.Or(p.Status, "unchanged")}
+	},
+	"currency": func(p Project) []string {
+		return []string{cmp.Or(p.Currency, "unknown")}
+	},
+	"skill": func(p Project) []string {
+		if len(p.Skills) == 0 {
+			return []string{"none"}
+		}
+		return slices.Compact(slices.Sorted(slices.Values(p.Skills)))
+	},
+	"country": func(p Project) []string {
+		return []string{cmp.Or(p.EmployerCountry, "unknown")}
+	},
+}
+
+// groupProjects buckets projects by the active --group-by key, preserving
+// their order within each group. It returns nil when grouping is off.
+func groupProjects(projects []Project) map[string][]Project {
+	keyFunc := groupKeyFuncs[groupBy]
+	if keyFunc == nil {
+		return nil
+	}
+	groups := make(map[string][]Project)
+	for _, p := range projects {
+		for _, key := range keyFunc(p) {
+			groups[key] = append(groups[key], p)
+		}
+	}
+	return groups
+}
+
+func writeJSON(filename string, data OutputData) bool {
+	data.SchemaVersion = schemaVersion
+	data.Projects = outputProjects(data.Projects)
+	if groups := groupProjects(data.Projects); groups != nil {
+		data.GroupBy = groupBy
+		data.Groups = groups
+	}
+	var v any = data
+	if bare {
+		v = data.Projects
+	}
+	content, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
 		log.Println("Error marshalling JSON:", err)
-		return
+		return false
 	}
-	if err := os.WriteFile(filename, file, 0644); err != nil {
+	file, err := createOutput(filename)
+	if err != nil {
+		log.Println("Error creating JSON file:", err)
+		return false
+	}
+	defer file.Close()
+	if _, err := file.Write(content); err != nil {
 		log.Println("Error writing JSON file:", err)
-		return
+		return false
+	}
+	if err := file.Commit(); err != nil {
+		log.Println("Error writing JSON file:", err)
+		return false
 	}
 	fmt.Println("Generated:", filename)
+	recordGenerated(filename, "json")
+	return true
 }
 
-func writeCSV(filename string, projects []Project, params map[string]string) {
-	file, err := os.Create(filename)
+func writeCSV(filename string, data OutputData) bool {
+	file, err := createOutput(filename)
 	if err != nil {
 		log.Println("Error creating CSV file:", err)
-		return
+		return false
 	}
 	defer file.Close()
 
+	// RFC 4180: CRLF line endings, fields quoted by encoding/csv as needed,
+	// and no pseudo-comment rows unless --csv-comments asks for them.
+	// Excel only reads CSV as UTF-8 when it starts with a byte order mark.
+	if csvBOM {
+		if _, err := io.WriteString(file, "\ufeff"); err != nil {
+			log.Println("Error writing CSV file:", err)
+			return false
+		}
+	}
 	writer := csv.NewWriter(file)
-	defer writer.Flush()
+	writer.UseCRLF = true
 
-	writer.Write([]string{"# Parameters Used:"})
-	for k, v := range params {
-		writer.Write([]string{"# " + k + ": " + v})
+	if csvComments && !bare {
+		writer.Write([]string{"# Parameters Used:"})
+		for _, k := range slices.Sorted(maps.Keys(data.Parameters)) {
+			writer.Write([]string{"# " + k + ": " + data.Parameters[k]})
+		}
+		if data.TotalResults > 0 {
+			writer.Write([]string{fmt.Sprintf("# Total results: %d (%d pages)", data.TotalResults, data.TotalPages)})
+		}
+		if data.Command != "" {
+			writer.Write([]string{"# Command: " + data.Command})
+		}
 	}
 
-	header := []string{"Title", "Time Left", "Bids", "Price/AvgBid", "Link", "Description"}
+	header := []string{"Title", "Time Left", "Posted At", "Bids", "Price/AvgBid", "Budget Min", "Budget Max", "Currency", "Link", "Description"}
+	if diffBaseline != "" {
+		header = append([]string{"Status"}, header...)
+	}
+	if withIndex {
+		header = append([]string{"#"}, header...)
+	}
 	writer.Write(header)
 
-	for _, p := range projects {
-		row := []string{
+	for _, p := range data.Projects {
+		var row []string
+		if withIndex {
+			row = append(row, strconv.Itoa(p.Index))
+		}
+		if diffBaseline != "" {
+			row = append(row, p.Status)
+		}
+		row = append(row,
 			p.Title,
 			p.TimeLeft,
+			formatPostedAt(p.PostedAt),
 			p.BidsCount,
-			p.Budget,
+			priceText(p),
+			formatAmount(p.BudgetMin, p.Currency),
+			formatAmount(p.BudgetMax, p.Currency),
+			p.Currency,
 			p.Link,
-			strings.ReplaceAll(p.Description, "\n", " "),
-		}
+			shownDescription(p),
+		)
 		writer.Write(row)
 	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Println("Error writing CSV file:", err)
+		return false
+	}
+	if err := file.Commit(); err != nil {
+		log.Println("Error writing CSV file:", err)
+		return false
+	}
 	fmt.Println("Generated:", filename)
+	recordGenerated(filename, "csv")
+	return true
 }
 
-func writeMarkdown(filename string, projects []Project, params map[string]string) {
-	file, err := os.Create(filename)
+func writeMarkdown(filename string, data OutputData) bool {
+	file, err := createOutput(filename)
 	if err != nil {
 		log.Println("Error creating Markdown file:", err)
-		return
+		return false
 	}
 	defer file.Close()
 
@@ -348,23 +1203,96 @@ func writeMarkdown(filename string, projects []Project, params map[string]string
 
 	sb.WriteString("# Freelancer.com Projects\n\n")
 	sb.WriteString(fmt.Sprintf("**Generated:** %s\n\n", time.Now().Format(time.RFC1123)))
+	if data.TotalResults > 0 {
+		sb.WriteString(fmt.Sprintf("**Results:** showing %d of %d (%d pages)\n\n", len(data.Projects), data.TotalResults, data.TotalPages))
+	}
 
 	sb.WriteString("### Search Parameters\n")
 	sb.WriteString("| Parameter | Value |\n| --- | --- |\n")
-	for k, v := range params {
-		sb.WriteString(fmt.Sprintf("| %s | %s |\n", k, v))
+	// Sorted, so identical searches produce identical files.
+	for _, k := range slices.Sorted(maps.Keys(data.Parameters)) {
+		sb.WriteString(fmt.Sprintf("| %s | %s |\n", k, data.Parameters[k]))
+	}
+	if data.Command != "" {
+		sb.WriteString(fmt.Sprintf("\nReproduce with:\n\n```sh\n%s\n```\n", data.Command))
 	}
 	sb.WriteString("\n---\n\n")
 
-	for _, p := range projects {
-		sb.WriteString(fmt.Sprintf("## [%s](%s)\n", strings.TrimSpace(p.Title), p.Link))
-		sb.WriteString(fmt.Sprintf("- **Budget/Price:** %s\n", p.Budget))
-		sb.WriteString(fmt.Sprintf("- **Bids:** %s\n", p.BidsCount))
-		sb.WriteString(fmt.Sprintf("- **Time:** %s\n", p.TimeLeft))
-		sb.WriteString(fmt.Sprintf("\n> %s\n\n", p.Description))
-		sb.WriteString("---\n")
+	if groups := groupProjects(data.Projects); groups != nil {
+		keys := make([]string, 0, len(groups))
+		for k := range groups {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sb.WriteString(fmt.Sprintf("## %s: %s (%d)\n\n", groupBy, k, len(groups[k])))
+			for _, p := range groups[k] {
+				writeMarkdownProject(&sb, p, "###")
+			}
+			sb.WriteString("\n")
+		}
+	} else {
+		for _, p := range data.Projects {
+			writeMarkdownProject(&sb, p, "##")
+		}
 	}
 
-	file.WriteString(sb.String())
+	if _, err := io.WriteString(file, sb.String()); err != nil {
+		log.Println("Error writing Markdown file:", err)
+		return false
+	}
+	if err := file.Commit(); err != nil {
+		log.Println("Error writing Markdown file:", err)
+		return false
+	}
 	fmt.Println("Generated:", filename)
+	recordGenerated(filename, "md")
+	return true
+}
+
+func writeMarkdownProject(sb *strings.Builder, p Project, heading string) {
+	if p.Index > 0 {
+		heading = fmt.Sprintf("%s %d.", heading, p.Index)
+	}
+	sb.WriteString(fmt.Sprintf("%s [%s](%s)\n", heading, strings.TrimSpace(p.Title), p.Link))
+	sb.WriteString(fmt.Sprintf("- **Budget/Price:** %s\n", priceText(p)))
+	if p.BudgetMin > 0 {
+		amount := formatAmount(p.BudgetMin, p.Currency)
+		if p.BudgetMax != p.BudgetMin {
+			amount += " - " + formatAmount(p.BudgetMax, p.Currency)
+		}
+		if !formatCurrency && p.Currency != "" {
+			amount += " " + p.Currency
+		}
+		sb.WriteString(fmt.Sprintf("- **Amount:** %s\n", amount))
+	}
+	sb.WriteString(fmt.Sprintf("- **Bids:** %s\n", p.BidsCount))
+	if p.EmployerCountry != "" {
+		sb.WriteString(fmt.Sprintf("- **Client:** %s\n", countryLabel(p.EmployerCountry)))
+	}
+	sb.WriteString(fmt.Sprintf("- **Time:** %s\n", p.TimeLeft))
+	if !p.PostedAt.IsZero() {
+		sb.WriteString(fmt.Sprintf("- **Posted:** %s\n", formatPostedAt(p.PostedAt)))
+	}
+	for _, change := range p.Changes {
+		sb.WriteString(fmt.Sprintf("- **Changed:** %s\n", change))
+	}
+	if p.DescriptionHTML != "" && (p.Summary == "" || descriptionText == "full") {
+		sb.WriteString(fmt.Sprintf("\n<blockquote>%s</blockquote>\n\n", p.DescriptionHTML))
+	} else {
+		// Every line of a --preserve-formatting description has to stay
+		// inside the quote.
+		quoted := strings.ReplaceAll(shownDescription(p), "\n", "  \n> ")
+		sb.WriteString(fmt.Sprintf("\n> %s\n\n", quoted))
+	}
+	sb.WriteString("---\n")
+}
+
+// formatPostedAt renders an estimated posting time for the text writers,
+// or "" when it's unknown.
+func formatPostedAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestMain registers the flags as main does, so every flag variable holds
+// its default while the tests run.
+func TestMain(m *testing.M) {
+	registerFlags()
+	os.Exit(m.Run())
+}
+
+func TestWriteCSVRoundTrip(t *testing.T) {
+	projects := []Project{
+		{Title: "Plain title", Link: "https://www.freelancer.com/projects/php/plain", Description: "Nothing special"},
+		{Title: "Commas, in, title", Link: "https://www.freelancer.com/projects/php/commas", Description: "Build a site, a logo, and a shop"},
+		{Title: `Fix "quoted" bug`, Link: "https://www.freelancer.com/projects/php/quotes", Description: `He said "it's broken", twice`},
+		{Title: "Multi-line", Link: "https://www.freelancer.com/projects/php/lines", Description: "First line\nSecond line\r\nThird, \"quoted\" line"},
+		{Title: "# Not a comment", Link: "https://www.freelancer.com/projects/php/hash", Description: ""},
+	}
+	path := filepath.Join(t.TempDir(), "out.csv")
+	writeCSV(path, OutputData{Parameters: map[string]string{"q": "go"}, Projects: projects})
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(raw, []byte("\r\n")) {
+		t.Errorf("CSV doesn't end in CRLF")
+	}
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV back: %v", err)
+	}
+	if len(records) != len(projects)+1 {
+		t.Fatalf("got %d records, want a header and %d rows", len(records), len(projects))
+	}
+	header := records[0]
+	if header[0] != "Title" {
+		t.Errorf("first record is %q, want the header", header)
+	}
+	col := func(name string) int { return slices.Index(header, name) }
+	for i, p := range projects {
+		row := records[i+1]
+		if row[col("Title")] != p.Title || row[col("Link")] != p.Link {
+			t.Errorf("row %d: got %q, %q; want %q, %q", i+1, row[col("Title")], row[col("Link")], p.Title, p.Link)
+		}
+		// encoding/csv reads a quoted \r\n back as \n.
+		want := strings.ReplaceAll(p.Description, "\r\n", "\n")
+		if row[col("Description")] != want {
+			t.Errorf("row %d: Description = %q, want %q", i+1, row[col("Description")], want)
+		}
+	}
+}
+
+func TestGroupProjects(t *testing.T) {
+	projects := []Project{
+		{Title: "A", Link: "/projects/a", PriceType: priceTypeFixed, Currency: "USD", Skills: []string{"PHP", "MySQL"}, EmployerCountry: "us"},
+		{Title: "B", Link: "/projects/b", PriceType: priceTypeHourly, Currency: "EUR", Skills: []string{"PHP"}, EmployerCountry: "de"},
+		{Title: "C", Link: "/projects/c", PriceType: priceTypeFixed, Skills: []string{"Go", "Go"}},
+		{Title: "D", Link: "/projects/d", PriceType: priceTypeHourly, Currency: "USD", EmployerCountry: "us", Status: "added"},
+	}
+	tests := []struct {
+		groupBy string
+		want    map[string][]string
+	}{
+		{"", nil},
+		{"type", map[string][]string{"fixed": {"A", "C"}, "hourly": {"B", "D"}}},
+		{"currency", map[string][]string{"USD": {"A", "D"}, "EUR": {"B"}, "unknown": {"C"}}},
+		{"status", map[string][]string{"unchanged": {"A", "B", "C"}, "added": {"D"}}},
+		{"skill", map[string][]string{"PHP": {"A", "B"}, "MySQL": {"A"}, "Go": {"C"}, "none": {"D"}}},
+		{"country", map[string][]string{"us": {"A", "D"}, "de": {"B"}, "unknown": {"C"}}},
+	}
+	defer func(old string) { groupBy = old }(groupBy)
+	for _, tt := range tests {
+		groupBy = tt.groupBy
+		groups := groupProjects(projects)
+		got := make(map[string][]string, len(groups))
+		for k, ps := range groups {
+			got[k] = titles(ps)
+		}
+		if tt.want == nil && groups != nil || !maps.EqualFunc(got, tt.want, slices.Equal) {
+			t.Errorf("--group-by %q: got %v, want %v", tt.groupBy, got, tt.want)
+		}
+	}
+}
+
+func TestReadGroupedOutputKeepsProjectsOnce(t *testing.T) {
+	defer func(old string) { groupBy = old }(groupBy)
+	groupBy = "skill"
+	projects := []Project{
+		{Title: "A", Link: "https://www.freelancer.com/projects/php/a", Skills: []string{"PHP", "MySQL"}},
+		{Title: "B", Link: "https://www.freelancer.com/projects/php/b", Skills: []string{"PHP"}},
+	}
+	// A file with only the groups, which list A under both of its skills.
+	raw, err := json.Marshal(OutputData{SchemaVersion: schemaVersion, GroupBy: groupBy, Groups: groupProjects(projects)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "grouped.json")
+	if err := os.WriteFile(path, raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readOutputFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(read); len(got) != 2 {
+		t.Errorf("read back %q, want A and B once each", got)
+	}
+}
+
+func TestHandleOutputExtensionVariants(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags [][2]string
+		file  string // "" for the timestamped name
+	}{
+		{"-X json", [][2]string{{"extension", "json"}}, ""},
+		{"-X .json", [][2]string{{"extension", ".json"}}, ""},
+		{"-X JSON", [][2]string{{"extension", "JSON"}}, ""},
+		{"-X ' .Json '", [][2]string{{"extension", " .Json "}}, ""},
+		{"-O out -X json", [][2]string{{"output", "out"}, {"extension", "json"}}, "out.json"},
+		{"-O out -X .json", [][2]string{{"output", "out"}, {"extension", ".json"}}, "out.json"},
+		{"-O out -X JSON", [][2]string{{"output", "out"}, {"extension", "JSON"}}, "out.json"},
+		{"-O out.json", [][2]string{{"output", "out.json"}}, "out.json"},
+		{"-O out.JSON", [][2]string{{"output", "out.JSON"}}, "out.JSON"},
+		{"-O out.Json.gz", [][2]string{{"output", "out.Json.gz"}}, "out.Json.gz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(t)
+			dir := t.TempDir()
+			setFlags(t, append([][2]string{{"output-dir", dir}}, tt.flags...))
+			if !handleOutput(OutputData{Projects: []Project{{Title: "One"}}}) {
+				t.Fatal("handleOutput failed")
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("wrote %d files, want 1", len(entries))
+			}
+			name := entries[0].Name()
+			switch {
+			case tt.file != "" && name != tt.file:
+				t.Errorf("wrote %s, want %s", name, tt.file)
+			case tt.file == "" && (!strings.HasPrefix(name, outputPrefix) || !strings.HasSuffix(name, ".json")):
+				t.Errorf("wrote %s, want a timestamped .json file", name)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasSuffix(name, ".gz") {
+				if !bytes.HasPrefix(content, []byte{0x1f, 0x8b}) {
+					t.Errorf("%s isn't gzipped", name)
+				}
+				return
+			}
+			var data OutputData
+			if err := json.Unmarshal(content, &data); err != nil || len(data.Projects) != 1 {
+				t.Errorf("%s isn't the JSON output (%v):\n%s", name, err, content)
+			}
+		})
+	}
+}
+
+func TestCSVBOMOnlyWhenRequested(t *testing.T) {
+	bom := []byte{0xEF, 0xBB, 0xBF}
+	data := OutputData{Projects: []Project{{Title: "Café menu", Description: "Crème brûlée, ñandú"}}}
+	for _, withBOM := range []bool{false, true} {
+		resetFlags(t)
+		if withBOM {
+			setFlags(t, [][2]string{{"csv-bom", "true"}})
+		}
+		dir := t.TempDir()
+		files := map[string]func(string, OutputData) bool{
+			"out.csv":  writeCSV,
+			"out.json": writeJSON,
+			"out.md":   writeMarkdown,
+		}
+		for name, write := range files {
+			path := filepath.Join(dir, name)
+			if !write(path, data) {
+				t.Fatalf("writing %s failed", name)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantBOM := withBOM && name == "out.csv"
+			if got := bytes.HasPrefix(content, bom); got != wantBOM {
+				t.Errorf("--csv-bom=%v: %s starts with a BOM = %v, want %v", withBOM, name, got, wantBOM)
+			}
+			if !bytes.Contains(content, []byte("Café menu")) {
+				t.Errorf("--csv-bom=%v: %s doesn't hold the title as UTF-8:\n%s", withBOM, name, content)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestFile describes one file written by a run, for --manifest. A
+// stream (see isStream) has no size or checksum: what was written to it
+// can't be read back.
+type manifestFile struct {
+	Path       string `json:"path"`
+	Format     string `json:"format"`
+	Compressed bool   `json:"compressed,omitempty"`
+	Stream     bool   `json:"stream,omitempty"`
+	Size       *int64 `json:"size,omitempty"`
+	SHA256     string `json:"sha256,omitempty"`
+}
+
+// manifest is the --manifest file: every file a run wrote, with the search
+// that produced them, so the next stage of a pipeline can find its input.
+type manifest struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Command     string            `json:"command,omitempty"`
+	Parameters  map[string]string `json:"parameters"`
+	Projects    int               `json:"projects"`
+	Files       []manifestFile    `json:"files"`
+}
+
+// generatedFiles lists the files written so far in this run, in order.
+var generatedFiles struct {
+	sync.Mutex
+	paths   []string
+	formats []string
+}
+
+// recordGenerated notes a file written in format, for the manifest.
+func recordGenerated(path, format string) {
+	generatedFiles.Lock()
+	defer generatedFiles.Unlock()
+	generatedFiles.paths = append(generatedFiles.paths, path)
+	generatedFiles.formats = append(generatedFiles.formats, format)
+}
+
+// writeManifest writes the manifest of the files recorded by
+// recordGenerated to path.
+func writeManifest(path string, data OutputData) {
+	m := manifest{
+		GeneratedAt: time.Now().UTC().Truncate(time.Second),
+		Command:     data.Command,
+		Parameters:  data.Parameters,
+		Projects:    len(data.Projects),
+		Files:       []manifestFile{},
+	}
+	generatedFiles.Lock()
+	for i, p := range generatedFiles.paths {
+		f, err := describeFile(p)
+		if err != nil {
+			log.Printf("Warning: leaving %s out of the manifest: %v", p, err)
+			continue
+		}
+		f.Format = generatedFiles.formats[i]
+		m.Files = append(m.Files, f)
+	}
+	generatedFiles.Unlock()
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		log.Println("Error marshalling manifest:", err)
+		return
+	}
+	if err := writeFileAtomic(path, append(content, '\n')); err != nil {
+		log.Println("Error writing manifest:", err)
+		return
+	}
+	fmt.Println("Generated:", path)
+}
+
+// describeFile returns the size and checksum of the file at path. A stream
+// is never opened: reading a FIFO would wait for a writer that's gone, and
+// reading a device such as a terminal would read its input.
+func describeFile(path string) (manifestFile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	compressed := strings.HasSuffix(strings.ToLower(path), ".gz")
+	if isStream(path) {
+		return manifestFile{Path: abs, Compressed: compressed, Stream: true}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return manifestFile{}, err
+	}
+	return manifestFile{
+		Path:       abs,
+		Compressed: compressed,
+		Size:       &size,
+		SHA256:     hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
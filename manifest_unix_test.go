@@ -0,0 +1,74 @@
+//go:build unix
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestManifestListsFIFOWithoutReadingIt(t *testing.T) {
+	resetFlags(t)
+	generatedFiles.Lock()
+	generatedFiles.paths, generatedFiles.formats = nil, nil
+	generatedFiles.Unlock()
+
+	dir := t.TempDir()
+	fifo := filepath.Join(dir, "pipe")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Skipf("can't make a FIFO: %v", err)
+	}
+	read := make(chan string, 1)
+	go func() {
+		f, err := os.Open(fifo)
+		if err != nil {
+			read <- ""
+			return
+		}
+		defer f.Close()
+		b, _ := io.ReadAll(f)
+		read <- string(b)
+	}()
+
+	manifestFile := filepath.Join(dir, "manifest.json")
+	setFlags(t, [][2]string{{"output", fifo}, {"manifest", manifestFile}})
+	data := OutputData{Projects: []Project{{Title: "Piped project"}}}
+	if !handleOutput(data) {
+		t.Fatal("handleOutput failed")
+	}
+	if got := <-read; !strings.Contains(got, "Piped project") {
+		t.Fatalf("the FIFO's reader got %q", got)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		writeManifest(manifestFile, data)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writeManifest blocked on the FIFO")
+	}
+
+	raw, err := os.ReadFile(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m manifest
+	if err := json.Unmarshal(raw, &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 1 {
+		t.Fatalf("manifest lists %d files, want the FIFO:\n%s", len(m.Files), raw)
+	}
+	if f := m.Files[0]; f.Path != fifo || !f.Stream || f.Size != nil || f.SHA256 != "" || f.Format != "csv" {
+		t.Errorf("FIFO entry = %+v, want a csv stream with no size or checksum", f)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// mergeInto folds fresh projects into the master JSON file at path for
+// --merge-into. Projects already in it are replaced by their fresh version
+// with first_seen kept; new ones are appended. Every fresh project gets
+// last_seen = now. A missing or empty master starts a new one. The file is
+// rewritten through a temporary file and a rename, so a crash never leaves
+// it half-written. It returns how many projects were added and updated.
+func mergeInto(path string, fresh []Project, params map[string]string, now time.Time) (added, updated int, err error) {
+	var master []Project
+	if info, statErr := os.Stat(path); statErr == nil && info.Size() > 0 {
+		if master, err = readOutputFile(path); err != nil {
+			return 0, 0, fmt.Errorf("reading %s: %w", path, err)
+		}
+	} else if statErr != nil && !errors.Is(statErr, os.ErrNotExist) {
+		return 0, 0, statErr
+	}
+
+	index := make(map[string]int, len(master))
+	for i, p := range master {
+		index[projectKey(p)] = i
+	}
+	for _, p := range fresh {
+		p.Index = 0
+		p.LastSeen = now
+		if i, ok := index[projectKey(p)]; ok {
+			p.FirstSeen = master[i].FirstSeen
+			if p.FirstSeen.IsZero() {
+				p.FirstSeen = now
+			}
+			master[i] = p
+			updated++
+			continue
+		}
+		p.FirstSeen = now
+		index[projectKey(p)] = len(master)
+		master = append(master, p)
+		added++
+	}
+
+	data := OutputData{SchemaVersion: schemaVersion, Parameters: params, Projects: outputProjects(master)}
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return 0, 0, err
+	}
+	return added, updated, writeFileAtomic(path, content)
+}
+
+// writeFileAtomic writes content to path through createOutput, so it's
+// renamed into place whole and gzip-compressed when path ends in .gz.
+func writeFileAtomic(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	out, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if _, err := out.Write(content); err != nil {
+		return err
+	}
+	return out.Commit()
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// outputWriter is a file being written by one of the writers, gzip-compressed
+// when its name ends in .gz. It's written to a temporary file next to the
+// target and only renamed into place by Commit, so readers never see a
+// partial file and a failed write leaves any earlier file untouched. A named
+// pipe or device (see isStream) is written to directly instead.
+type outputWriter struct {
+	io.Writer
+	path   string
+	file   *os.File
+	gz     *gzip.Writer
+	stream bool
+}
+
+// isStream reports whether path is an existing named pipe, socket or device,
+// such as a FIFO another process reads from or /dev/stdout. Those are
+// written to as they are, since they can't be replaced by a rename.
+func isStream(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&(os.ModeNamedPipe|os.ModeSocket|os.ModeCharDevice) != 0
+}
+
+// createOutput starts writing filename, wrapping it in a gzip writer when
+// the name ends in .gz. Opening a FIFO blocks until it has a reader.
+func createOutput(filename string) (*outputWriter, error) {
+	var file *os.File
+	var err error
+	stream := isStream(filename)
+	if stream {
+		file, err = os.OpenFile(filename, os.O_WRONLY, 0)
+	} else {
+		file, err = os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".tmp*")
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := &outputWriter{Writer: file, path: filename, file: file, stream: stream}
+	if strings.HasSuffix(strings.ToLower(filename), ".gz") {
+		out.gz = gzip.NewWriter(file)
+		out.Writer = out.gz
+	}
+	return out, nil
+}
+
+// Commit flushes any compressed data, syncs the temporary file and renames
+// it over the target. If any step fails the target is left as it was. A
+// stream is just flushed and closed.
+func (o *outputWriter) Commit() error {
+	if o.file == nil {
+		return nil
+	}
+	file := o.file
+	o.file = nil
+	var err error
+	if o.gz != nil {
+		err = o.gz.Close()
+	}
+	if o.stream {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err == nil {
+		err = file.Sync()
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(file.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), o.path)
+	}
+	return err
+}
+
+// Close abandons the output unless Commit has been called, removing the
+// temporary file. Writers defer it so every early return cleans up.
+func (o *outputWriter) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Close()
+	if !o.stream {
+		os.Remove(o.file.Name())
+	}
+	o.file = nil
+	return err
+}
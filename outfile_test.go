@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingWriter fails every write, like a full disk.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("no space left on device") }
+
+// checkOnlyFile fails t unless path still holds want and nothing else is
+// left in its directory.
+func checkOnlyFile(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("directory holds %q, want only %s", names, filepath.Base(path))
+	}
+}
+
+func TestOutputWriterFailedWriteKeepsOriginal(t *testing.T) {
+	for _, name := range []string{"out.csv", "out.json.gz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte("good"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			out, err := createOutput(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := out.Write([]byte("partial")); err != nil {
+				t.Fatal(err)
+			}
+			out.Writer = failingWriter{}
+			if _, err := out.Write([]byte("rest")); err == nil {
+				t.Fatal("write succeeded, want the simulated error")
+			}
+			out.Close()
+			checkOnlyFile(t, path, "good")
+		})
+	}
+}
+
+func TestWriteJSONErrorKeepsOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	if err := os.WriteFile(path, []byte("good"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// NaN can't be encoded, so the write fails.
+	writeJSON(path, OutputData{Projects: []Project{{Title: "Bad", BudgetMin: math.NaN()}}})
+	checkOnlyFile(t, path, "good")
+}
+
+func TestOutputWriterCommitReplacesOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out, err := createOutput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+	if _, err := out.Write([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := out.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	checkOnlyFile(t, path, "new")
+}
+
+func TestHandleOutputRejectsGzipStream(t *testing.T) {
+	if !isStream(os.DevNull) {
+		t.Skipf("%s is not a device here", os.DevNull)
+	}
+	resetFlags(t)
+	data := OutputData{Projects: []Project{{Title: "One"}}}
+
+	setFlags(t, [][2]string{{"output", os.DevNull}, {"gzip", "true"}})
+	if handleOutput(data) {
+		t.Errorf("-O %s --gzip was written uncompressed, want an error", os.DevNull)
+	}
+	setFlags(t, [][2]string{{"gzip", "false"}})
+	if !handleOutput(data) {
+		t.Errorf("-O %s failed without --gzip", os.DevNull)
+	}
+}
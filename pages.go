@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pageList returns the result pages to fetch for each query: the --pages
+// range when it's set, otherwise just --page.
+func pageList() ([]int, error) {
+	if pagesRange == "" {
+		return []int{max(pageNumber, 1)}, nil
+	}
+	return parsePageRange(pagesRange)
+}
+
+// parsePageRange parses "N" or "FROM-TO" into the list of pages it covers.
+func parsePageRange(s string) ([]int, error) {
+	fromStr, toStr, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	from, err := strconv.Atoi(strings.TrimSpace(fromStr))
+	if err != nil || from < 1 {
+		return nil, fmt.Errorf("invalid page range %q: expected N or FROM-TO with pages starting at 1", s)
+	}
+	to := from
+	if isRange {
+		to, err = strconv.Atoi(strings.TrimSpace(toStr))
+		if err != nil || to < from {
+			return nil, fmt.Errorf("invalid page range %q: expected N or FROM-TO with FROM <= TO", s)
+		}
+	}
+	pages := make([]int, 0, to-from+1)
+	for p := from; p <= to; p++ {
+		pages = append(pages, p)
+	}
+	return pages, nil
+}
+
+// checkpoint persists each successfully scraped page of a run so an
+// interrupted or blocked run can pick up where it left off with --resume.
+// A nil *checkpoint is valid and does nothing.
+type checkpoint struct {
+	mu   sync.Mutex
+	path string
+
+	// Search identifies the queries, filters and pages the saved pages
+	// belong to, so a checkpoint is never resumed into a different search.
+	Search string           `json:"search"`
+	Pages  []checkpointPage `json:"pages"`
+}
+
+type checkpointPage struct {
+	Query  string      `json:"query"`
+	Page   int         `json:"page"`
+	Result *PageResult `json:"result"`
+}
+
+// searchSignature identifies a run's queries and pages for its checkpoint.
+func searchSignature(queries []string, pages []int) string {
+	h := sha256.New()
+	for _, q := range queries {
+		u, _ := buildURL(q, 1)
+		fmt.Fprintln(h, u)
+	}
+	fmt.Fprintln(h, pages)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// openCheckpoint returns the checkpoint at path for the given search, or nil
+// when checkpointing is off. With resume, pages saved by an earlier run of the
+// same search are loaded; otherwise any old checkpoint is ignored.
+func openCheckpoint(path, search string, resume bool) (*checkpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+	cp := &checkpoint{path: path, Search: search}
+	if !resume {
+		return cp, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		fmt.Printf("No checkpoint at %s; starting from the beginning.\n", path)
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved checkpoint
+	if err := json.Unmarshal(raw, &saved); err != nil {
+		return nil, fmt.Errorf("reading checkpoint %s: %w", path, err)
+	}
+	if saved.Search != search {
+		return nil, fmt.Errorf("checkpoint %s was written for a different search; remove it or drop --resume", path)
+	}
+	cp.Pages = saved.Pages
+	fmt.Printf("Resuming from %s: %d pages already done.\n", path, len(cp.Pages))
+	return cp, nil
+}
+
+// lookup returns the saved result for a page, or nil if it still needs
+// fetching.
+func (c *checkpoint) lookup(query string, page int) *PageResult {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, p := range c.Pages {
+		if p.Query == query && p.Page == page {
+			return p.Result
+		}
+	}
+	return nil
+}
+
+// record saves a freshly scraped page and rewrites the checkpoint file.
+func (c *checkpoint) record(query string, page int, result *PageResult) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Pages = append(c.Pages, checkpointPage{Query: query, Page: page, Result: result})
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// finish removes the checkpoint once every query completed, and otherwise
+// points the user at --resume.
+func (c *checkpoint) finish(results []queryResult) {
+	if c == nil {
+		return
+	}
+	for _, qr := range results {
+		if qr.err != nil || qr.interrupted {
+			fmt.Printf("Progress saved to %s; re-run with --resume to continue.\n", c.path)
+			return
+		}
+	}
+	os.Remove(c.path)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SearchParams is the typed form of the string parameter map, for
+// consumers that would rather not parse "1-5" or "us,gb" themselves. Only
+// parameters that were set are included.
+type SearchParams struct {
+	Query            string   `json:"query,omitempty"`
+	Queries          []string `json:"queries,omitempty"`
+	Types            []string `json:"types,omitempty"`
+	Skills           []string `json:"skills,omitempty"`
+	ClientCountries  []string `json:"client_countries,omitempty"`
+	ExcludeCountries []string `json:"exclude_countries,omitempty"`
+	Upgrades         []string `json:"upgrades,omitempty"`
+	Currencies       []string `json:"currencies,omitempty"`
+	FixedPriceMin    *int     `json:"fixed_price_min,omitempty"`
+	FixedPriceMax    *int     `json:"fixed_price_max,omitempty"`
+	HourlyRateMin    *int     `json:"hourly_rate_min,omitempty"`
+	HourlyRateMax    *int     `json:"hourly_rate_max,omitempty"`
+	MinRating        float64  `json:"min_rating,omitempty"`
+	MinReviews       int      `json:"min_reviews,omitempty"`
+	Sort             string   `json:"sort,omitempty"`
+	FirstPage        int      `json:"first_page,omitempty"`
+	LastPage         int      `json:"last_page,omitempty"`
+	API              bool     `json:"api,omitempty"`
+	Partial          string   `json:"partial,omitempty"`
+}
+
+// typedParams converts the parameter map written by buildURL and
+// mergeQueryResults into SearchParams. Values that don't parse are left
+// zero; the map still has them verbatim.
+func typedParams(params map[string]string) *SearchParams {
+	s := &SearchParams{
+		Query:            params["q"],
+		Queries:          splitParam(params["queries"], ";"),
+		Types:            splitParam(params["types"], ","),
+		ClientCountries:  splitParam(params["clientCountries"], ","),
+		ExcludeCountries: splitParam(params["excludeCountries"], ","),
+		Upgrades:         splitParam(params["projectUpgrades"], ","),
+		Currencies:       splitParam(params["currency"], ","),
+		Sort:             params["projectSort"],
+		API:              params["endpoint"] == "api",
+		Partial:          params["partial"],
+	}
+	if v := params["projectSkills"]; v != "all" {
+		s.Skills = splitParam(v, ",")
+	}
+	s.FixedPriceMin = intParam(params["projectFixedPriceMin"])
+	s.FixedPriceMax = intParam(params["projectFixedPriceMax"])
+	s.HourlyRateMin = intParam(params["projectHourlyRateMin"])
+	s.HourlyRateMax = intParam(params["projectHourlyRateMax"])
+	s.MinRating, _ = strconv.ParseFloat(params["minRating"], 64)
+	s.MinReviews, _ = strconv.Atoi(params["minReviews"])
+
+	switch {
+	case params["pages"] != "":
+		first, last, _ := strings.Cut(params["pages"], "-")
+		s.FirstPage, _ = strconv.Atoi(first)
+		s.LastPage, _ = strconv.Atoi(last)
+	case params["page"] != "":
+		s.FirstPage, _ = strconv.Atoi(params["page"])
+		s.LastPage = s.FirstPage
+	default:
+		s.FirstPage, s.LastPage = 1, 1
+	}
+	return s
+}
+
+// intParam parses a price parameter, returning nil when it's absent or
+// invalid so that an explicit 0 is kept.
+func intParam(v string) *int {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// splitParam splits a list parameter on sep, dropping empty items.
+func splitParam(v, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(v, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/spf13/cobra"
+)
+
+// pickMatches caps how many search matches the picker lists at once.
+const pickMatches = 15
+
+var pickCmd = &cobra.Command{
+	Use:   "pick",
+	Short: "Interactively choose skills and client countries, then scrape",
+	Long: `Search Freelancer's skill list and the client countries by name, select
+the ones you want, and run the scrape with them. The equivalent flags are
+printed so the same search can be scripted next time.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var p prompter = &linePrompter{in: bufio.NewReader(os.Stdin), w: os.Stdout}
+		if isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+			p = surveyPrompter{}
+		}
+		if runPicker(p, os.Stdout) {
+			runScraper()
+		}
+	},
+}
+
+type pickItem struct {
+	Key   string
+	Label string
+}
+
+// prompter asks the picker's questions. surveyPrompter shows fuzzy-searchable
+// menus on a terminal; linePrompter reads plain lines, for piped input.
+type prompter interface {
+	// choose lets the user select any of items, returning their keys.
+	choose(label string, items []pickItem) ([]string, error)
+	// input asks for a line of text.
+	input(message string) (string, error)
+	// confirm asks a yes/no question, yes by default.
+	confirm(message string) (bool, error)
+}
+
+// runPicker asks for skills and client countries and sets the matching
+// flags, as if they had been given on the command line, so the run's
+// recorded command reproduces the search. It reports whether to run the
+// search now.
+func runPicker(p prompter, w io.Writer) bool {
+	var skillIDs []string
+	catalog, err := loadSkillCatalog(newHTTPClient())
+	if err != nil {
+		fmt.Fprintf(w, "Couldn't load Freelancer's skill list (%v).\n", err)
+		line, err := p.input("Enter skill IDs separated by commas (empty keeps the default):")
+		if err != nil {
+			fmt.Fprintln(w, "Cancelled.")
+			return false
+		}
+		for _, id := range strings.Split(line, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				skillIDs = append(skillIDs, id)
+			}
+		}
+	} else {
+		items := make([]pickItem, len(catalog))
+		for i, s := range catalog {
+			items[i] = pickItem{Key: strconv.Itoa(s.ID), Label: s.Name}
+		}
+		if skillIDs, err = p.choose("skills", items); err != nil {
+			fmt.Fprintln(w, "Cancelled.")
+			return false
+		}
+	}
+
+	countryItems := make([]pickItem, 0, len(countryNames))
+	for code, name := range countryNames {
+		countryItems = append(countryItems, pickItem{Key: code, Label: name})
+	}
+	sort.Slice(countryItems, func(i, j int) bool { return countryItems[i].Label < countryItems[j].Label })
+	countries, err := p.choose("countries", countryItems)
+	if err != nil {
+		fmt.Fprintln(w, "Cancelled.")
+		return false
+	}
+
+	if len(skillIDs) > 0 {
+		if err := commandFlags.Set("skills", strings.Join(skillIDs, ",")); err != nil {
+			fatalf("Error: --skills: %v", err)
+		}
+	}
+	if len(countries) > 0 {
+		if err := commandFlags.Set("clientCountries", strings.Join(countries, ",")); err != nil {
+			fatalf("Error: --clientCountries: %v", err)
+		}
+	}
+	fmt.Fprintf(w, "\nEquivalent command:\n  %s\n\n", reproduceCommand(commandFlags))
+
+	run, err := p.confirm("Run this search now?")
+	return err == nil && run
+}
+
+// isTerminal reports whether f is an interactive terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// surveyPrompter asks through terminal menus: a multi-select list that
+// narrows as the user types, fuzzily, and is toggled with the space bar.
+type surveyPrompter struct{}
+
+func (surveyPrompter) choose(label string, items []pickItem) ([]string, error) {
+	options := make([]string, len(items))
+	keys := make(map[string]string, len(items))
+	for i, item := range items {
+		options[i] = fmt.Sprintf("%s (%s)", item.Label, item.Key)
+		keys[options[i]] = item.Key
+	}
+	var picked []string
+	prompt := &survey.MultiSelect{
+		Message:  fmt.Sprintf("Choose %s (type to search, space to select, enter when done):", label),
+		Options:  options,
+		PageSize: pickMatches,
+	}
+	err := survey.AskOne(prompt, &picked, survey.WithFilter(func(filter, value string, _ int) bool {
+		return fuzzyMatch(filter, value)
+	}))
+	if err != nil {
+		return nil, err
+	}
+	selected := make([]string, len(picked))
+	for i, option := range picked {
+		selected[i] = keys[option]
+	}
+	return selected, nil
+}
+
+func (surveyPrompter) input(message string) (string, error) {
+	var line string
+	err := survey.AskOne(&survey.Input{Message: message}, &line)
+	return line, err
+}
+
+func (surveyPrompter) confirm(message string) (bool, error) {
+	yes := true
+	err := survey.AskOne(&survey.Confirm{Message: message, Default: true}, &yes)
+	return yes, err
+}
+
+// linePrompter asks through plain lines of text, for when the input isn't a
+// terminal: see pickItems.
+type linePrompter struct {
+	in *bufio.Reader
+	w  io.Writer
+}
+
+func (lp *linePrompter) choose(label string, items []pickItem) ([]string, error) {
+	return pickItems(lp.in, lp.w, label, items), nil
+}
+
+func (lp *linePrompter) input(message string) (string, error) {
+	fmt.Fprint(lp.w, message+" ")
+	line, err := lp.in.ReadString('\n')
+	if err == io.EOF {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
+
+func (lp *linePrompter) confirm(message string) (bool, error) {
+	answer, err := lp.input(message + " [Y/n]")
+	answer = strings.ToLower(answer)
+	return answer == "" || answer == "y" || answer == "yes", err
+}
+
+// pickItems runs a small search-and-toggle loop: text searches the items,
+// numbers toggle entries from the last search, and an empty line finishes.
+// It returns the keys of the selected items in selection order.
+func pickItems(in *bufio.Reader, w io.Writer, label string, items []pickItem) []string {
+	fmt.Fprintf(w, "\nChoose %s: type part of a name to search, numbers to toggle, an empty line when done.\n", label)
+
+	var selected []string
+	isSelected := func(key string) int {
+		for i, k := range selected {
+			if k == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	var shown []pickItem
+	for {
+		fmt.Fprintf(w, "%s> ", label)
+		line, err := in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if err != nil && err != io.EOF {
+				log.Println("Error reading input:", err)
+			}
+			return selected
+		}
+
+		if nums, ok := parseNumbers(line); ok {
+			for _, n := range nums {
+				if n < 1 || n > len(shown) {
+					fmt.Fprintf(w, "  no entry %d in the last search\n", n)
+					continue
+				}
+				item := shown[n-1]
+				if i := isSelected(item.Key); i >= 0 {
+					selected = append(selected[:i], selected[i+1:]...)
+				} else {
+					selected = append(selected, item.Key)
+				}
+			}
+		} else {
+			shown = fuzzyFind(items, line, pickMatches)
+			if len(shown) == 0 {
+				fmt.Fprintln(w, "  no matches")
+			}
+		}
+
+		for i, item := range shown {
+			mark := " "
+			if isSelected(item.Key) >= 0 {
+				mark = "x"
+			}
+			fmt.Fprintf(w, "  %2d. [%s] %s (%s)\n", i+1, mark, item.Label, item.Key)
+		}
+		if len(selected) > 0 {
+			fmt.Fprintf(w, "  selected: %s\n", strings.Join(selected, ","))
+		}
+	}
+}
+
+// parseNumbers parses a line of space- or comma-separated numbers.
+func parseNumbers(line string) ([]int, bool) {
+	fields := strings.FieldsFunc(line, func(r rune) bool { return r == ' ' || r == ',' })
+	nums := make([]int, 0, len(fields))
+	for _, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+	return nums, len(nums) > 0
+}
+
+// fuzzyFind returns up to limit items whose label or key matches query,
+// best first: exact matches, then prefixes, then substrings, then labels
+// containing the query's characters in order.
+func fuzzyFind(items []pickItem, query string, limit int) []pickItem {
+	query = strings.ToLower(query)
+	type scored struct {
+		item  pickItem
+		score int
+	}
+	var matches []scored
+	for _, item := range items {
+		label := strings.ToLower(item.Label)
+		score := 0
+		switch {
+		case label == query || strings.ToLower(item.Key) == query:
+			score = 4
+		case strings.HasPrefix(label, query):
+			score = 3
+		case strings.Contains(label, query):
+			score = 2
+		case isSubsequence(query, label):
+			score = 1
+		}
+		if score > 0 {
+			matches = append(matches, scored{item, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return len(matches[i].item.Label) < len(matches[j].item.Label)
+	})
+
+	result := make([]pickItem, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		result = append(result, m.item)
+	}
+	return result
+}
+
+// fuzzyMatch reports whether query matches label the way fuzzyFind scores
+// it: as a substring or as characters appearing in order.
+func fuzzyMatch(query, label string) bool {
+	query, label = strings.ToLower(query), strings.ToLower(label)
+	return strings.Contains(label, query) || isSubsequence(query, label)
+}
+
+// isSubsequence reports whether the runes of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	rs := []rune(sub)
+	i := 0
+	for _, r := range s {
+		if i < len(rs) && r == rs[i] {
+			i++
+		}
+	}
+	return i == len(rs)
+}
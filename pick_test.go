@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRunPickerSetsFlags(t *testing.T) {
+	resetFlags(t)
+	withSkillCatalog(t, `[{"id": 3, "name": "PHP"}, {"id": 13, "name": "Python"}, {"id": 17, "name": "Golang"}]`)
+
+	// Search and pick Golang and PHP, then the United Kingdom, and decline
+	// to run.
+	input := "golang\n1\nphp\n1\n\nunited kingdom\n1\n\nn\n"
+	p := &linePrompter{in: bufio.NewReader(strings.NewReader(input)), w: io.Discard}
+	var out strings.Builder
+	if runPicker(p, &out) {
+		t.Error("runPicker says to run the search after the user declined")
+	}
+
+	if skills != "17,3" || strings.Join(clientCountries, ",") != "gb" {
+		t.Errorf("skills, clientCountries = %q, %q; want 17,3 and gb", skills, clientCountries)
+	}
+	if !commandFlags.Changed("skills") || !commandFlags.Changed("clientCountries") {
+		t.Error("picked values weren't set through the flags")
+	}
+	want := "flparser --clientCountries=gb --skills=17,3"
+	if cmd := reproduceCommand(commandFlags); cmd != want {
+		t.Errorf("reproduceCommand = %q, want %q", cmd, want)
+	}
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("picker printed %q, want the equivalent command %q", out.String(), want)
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, label string
+		want         bool
+	}{
+		{"php", "PHP (3)", true},
+		{"uk", "United Kingdom (gb)", true},
+		{"kingdom", "United Kingdom (gb)", true},
+		{"gl", "Golang (17)", true},
+		{"xyz", "Golang (17)", false},
+		{"", "Anything", true},
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.query, tt.label); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.query, tt.label, got, tt.want)
+		}
+	}
+}
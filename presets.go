@@ -0,0 +1,150 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// builtinPresets are the skill presets shipped with flparser.
+//
+//go:embed presets.json
+var builtinPresets []byte
+
+// skillPreset is a named group of skills for --preset. Skills are IDs or
+// skill names as listed by Freelancer; names are looked up in the skill
+// list.
+type skillPreset struct {
+	Description string   `json:"description"`
+	Skills      []string `json:"skills"`
+}
+
+// presetsPath is the user's presets file, whose entries are added to the
+// built-in ones and replace those with the same name.
+func presetsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "flparser", "presets.json"), nil
+}
+
+// loadPresets returns the built-in presets merged with the user's.
+func loadPresets() (map[string]skillPreset, error) {
+	presets := make(map[string]skillPreset)
+	if err := json.Unmarshal(builtinPresets, &presets); err != nil {
+		return nil, fmt.Errorf("built-in presets: %w", err)
+	}
+	path, err := presetsPath()
+	if err != nil {
+		return presets, nil
+	}
+	raw, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var user map[string]skillPreset
+	if err := json.Unmarshal(raw, &user); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, p := range user {
+		presets[name] = p
+	}
+	return presets, nil
+}
+
+// presetSkillIDs resolves a preset's skills to IDs. Names are looked up in
+// the skill catalog, which is only loaded when the preset uses names; names
+// that aren't found are reported and skipped.
+func presetSkillIDs(client *http.Client, p skillPreset) ([]string, error) {
+	var ids []string
+	var byName map[string]int
+	for _, s := range p.Skills {
+		if _, err := strconv.Atoi(s); err == nil {
+			ids = append(ids, s)
+			continue
+		}
+		if byName == nil {
+			catalog, err := loadSkillCatalog(client)
+			if err != nil {
+				return nil, fmt.Errorf("loading Freelancer's skill list to look up %q: %w", s, err)
+			}
+			byName = make(map[string]int, len(catalog))
+			for _, skill := range catalog {
+				byName[strings.ToLower(skill.Name)] = skill.ID
+			}
+		}
+		id, ok := byName[strings.ToLower(s)]
+		if !ok {
+			log.Printf("Warning: skill %q isn't in Freelancer's skill list; skipping it", s)
+			continue
+		}
+		ids = append(ids, strconv.Itoa(id))
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("none of the preset's skills are known")
+	}
+	return ids, nil
+}
+
+// applyPreset sets --skills from the named preset.
+func applyPreset(client *http.Client, name string) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	p, ok := presets[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(presets))
+		return fmt.Errorf("unknown preset %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	ids, err := presetSkillIDs(client, p)
+	if err != nil {
+		return fmt.Errorf("preset %s: %w", name, err)
+	}
+	skills = strings.Join(ids, ",")
+	return nil
+}
+
+// listPresets prints every preset with its skills, named where the skill
+// list is available.
+func listPresets(w io.Writer, client *http.Client) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	names := make(map[int]string)
+	if catalog, err := loadSkillCatalog(client); err == nil {
+		for _, s := range catalog {
+			names[s.ID] = s.Name
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(presets)) {
+		p := presets[k]
+		fmt.Fprintf(w, "%s: %s\n", k, p.Description)
+		labels := make([]string, len(p.Skills))
+		for i, s := range p.Skills {
+			labels[i] = s
+			if id, err := strconv.Atoi(s); err == nil && names[id] != "" {
+				labels[i] = fmt.Sprintf("%s (%d)", names[id], id)
+			}
+		}
+		fmt.Fprintf(w, "  %s\n", strings.Join(labels, ", "))
+	}
+	if path, err := presetsPath(); err == nil {
+		fmt.Fprintf(w, "\nAdd your own presets, or override these, in %s.\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Patterns used by parsePrice. An amount is a run of digits with optional
+// thousands separators and decimals, and an optional "k" for thousands; a
+// currency is a standalone three-letter ISO code.
+var (
+	amountPattern   = regexp.MustCompile(`\d[\d.,]*(?:[kK]\b)?`)
+	currencyPattern = regexp.MustCompile(`\b[A-Z]{3}\b`)
+	perHourPattern  = regexp.MustCompile(`(?i)/\s*(hr|hour)\b|per\s+hour`)
+)
+
+// parsePrice extracts the numeric range, ISO currency code (see
+// resolveCurrency) and whether the price is per hour from card price text
+// such as "$30-250 USD", "₹12500-37500 INR", "$1.5k-3k USD" or
+// "$15.00 - $25.00 USD / hr". A single amount yields min == max. Text with
+// no amount, like "N/A", yields zero amounts; missing parts are returned as
+// zero values.
+func parsePrice(text string) (min, max float64, currency string, perHour bool) {
+	var amounts []float64
+	for _, field := range amountPattern.FindAllString(text, -1) {
+		scale := 1.0
+		if strings.HasSuffix(field, "k") || strings.HasSuffix(field, "K") {
+			field, scale = field[:len(field)-1], 1000
+		}
+		if v, ok := parseAmount(field); ok {
+			amounts = append(amounts, v*scale)
+		}
+	}
+	currency = resolveCurrency(text)
+	perHour = perHourPattern.MatchString(text)
+	switch {
+	case len(amounts) == 0:
+		return 0, 0, currency, perHour
+	case len(amounts) == 1:
+		return amounts[0], amounts[0], currency, perHour
+	default:
+		return amounts[0], amounts[1], currency, perHour
+	}
+}
+
+// symbolCurrencies maps the currency symbols that name a single currency to
+// its ISO code. Longer symbols come before any symbol they end with, so
+// "CA$" isn't read as "A$" and "NZ$" isn't read as "$". A bare "$" is
+// ambiguous and isn't listed.
+var symbolCurrencies = []struct{ symbol, code string }{
+	{"US$", "USD"},
+	{"NZ$", "NZD"},
+	{"HK$", "HKD"},
+	{"CA$", "CAD"},
+	{"AU$", "AUD"},
+	{"A$", "AUD"},
+	{"C$", "CAD"},
+	{"S$", "SGD"},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"₹", "INR"},
+}
+
+// resolveCurrency returns the ISO code for price text. A code in the text
+// ("$30-250 AUD") always wins, since the symbols alone are ambiguous; then a
+// symbol naming one currency ("€250"); then --default-currency for a bare
+// "$". Text with neither yields "".
+func resolveCurrency(text string) string {
+	if code := currencyPattern.FindString(text); code != "" {
+		return code
+	}
+	for _, sc := range symbolCurrencies {
+		if strings.Contains(text, sc.symbol) {
+			return sc.code
+		}
+	}
+	if strings.Contains(text, "$") {
+		return defaultCurrency
+	}
+	return ""
+}
+
+// parseAmount parses one number with optional separators. When both ',' and
+// '.' appear the later one is the decimal point; a lone separator is a
+// thousands separator when every group after it has exactly three digits
+// ("12,500", "2.000") and a decimal point otherwise ("15.00", "7,5").
+func parseAmount(s string) (float64, bool) {
+	s = strings.TrimRight(s, ".,")
+	lastComma, lastDot := strings.LastIndex(s, ","), strings.LastIndex(s, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0 || lastDot >= 0:
+		sep := ","
+		if lastDot >= 0 {
+			sep = "."
+		}
+		groups := strings.Split(s, sep)
+		thousands := len(groups) > 2
+		if !thousands {
+			thousands = len(groups[1]) == 3
+		}
+		for _, g := range groups[1:] {
+			if len(g) != 3 {
+				thousands = false
+			}
+		}
+		if thousands {
+			s = strings.ReplaceAll(s, sep, "")
+		} else if sep == "," {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// currencySymbols holds the symbols used when --format-currency renders an
+// amount. Unlisted currencies are shown with their code only.
+var currencySymbols = map[string]string{
+	"USD": "$",
+	"EUR": "€",
+	"GBP": "£",
+	"INR": "₹",
+	"AUD": "A$",
+	"CAD": "C$",
+	"NZD": "NZ$",
+	"SGD": "S$",
+	"HKD": "HK$",
+}
+
+// formatAmount renders an amount for the text writers: a plain number by
+// default so spreadsheets can do math on it, or with a currency symbol and
+// thousands separators under --format-currency.
+func formatAmount(v float64, currency string) string {
+	if v == 0 {
+		return ""
+	}
+	if !formatCurrency {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	var sb strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(r)
+	}
+	if frac != "00" {
+		sb.WriteString("." + frac)
+	}
+	if symbol, ok := currencySymbols[currency]; ok {
+		return symbol + sb.String()
+	}
+	if currency != "" {
+		return sb.String() + " " + currency
+	}
+	return sb.String()
+}
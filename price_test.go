@@ -0,0 +1,100 @@
+package main
+
+import "testing"
+
+func TestParsePrice(t *testing.T) {
+	defaultCurrency = "USD"
+	tests := []struct {
+		text     string
+		min, max float64
+		currency string
+		perHour  bool
+	}{
+		// Ranges.
+		{"$30-250 USD", 30, 250, "USD", false},
+		{"$30 - $250 USD", 30, 250, "USD", false},
+		{"₹12500-37500 INR", 12500, 37500, "INR", false},
+		{"$1,500 - $3,000 USD", 1500, 3000, "USD", false},
+		{"€1.500-2.000 EUR", 1500, 2000, "EUR", false},
+		// Single amounts.
+		{"€750 EUR", 750, 750, "EUR", false},
+		{"£1,250.50 GBP", 1250.5, 1250.5, "GBP", false},
+		{"€7,5 EUR", 7.5, 7.5, "EUR", false},
+		// Hourly.
+		{"$15.00 - $25.00 USD / hr", 15, 25, "USD", true},
+		{"$8-15 AUD /hour", 8, 15, "AUD", true},
+		{"$20 USD per hour", 20, 20, "USD", true},
+		// k suffix.
+		{"$1k-2k USD", 1000, 2000, "USD", false},
+		{"$1.5k - $3K USD", 1500, 3000, "USD", false},
+		{"₹50k INR", 50000, 50000, "INR", false},
+		// Currency codes and symbols.
+		{"$30-250 CAD", 30, 250, "CAD", false},
+		{"A$100", 100, 100, "AUD", false},
+		{"$45", 45, 45, "USD", false},
+		// Nothing to parse.
+		{"N/A", 0, 0, "", false},
+		{"", 0, 0, "", false},
+	}
+	for _, tt := range tests {
+		min, max, currency, perHour := parsePrice(tt.text)
+		if min != tt.min || max != tt.max || currency != tt.currency || perHour != tt.perHour {
+			t.Errorf("parsePrice(%q) = %v, %v, %q, %v; want %v, %v, %q, %v",
+				tt.text, min, max, currency, perHour, tt.min, tt.max, tt.currency, tt.perHour)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"12,500", 12500},
+		{"2.000", 2000},
+		{"15.00", 15},
+		{"7,5", 7.5},
+		{"1.234,56", 1234.56},
+		{"1,234.56", 1234.56},
+		{"1,234,567", 1234567},
+		{"250.", 250},
+	}
+	for _, tt := range tests {
+		got, ok := parseAmount(tt.in)
+		if !ok || got != tt.want {
+			t.Errorf("parseAmount(%q) = %v, %v; want %v", tt.in, got, ok, tt.want)
+		}
+	}
+}
+
+func TestResolveCurrency(t *testing.T) {
+	defer func(old string) { defaultCurrency = old }(defaultCurrency)
+	tests := []struct {
+		text, defaultCurrency, want string
+	}{
+		{"CA$250", "USD", "CAD"},
+		{"AU$250", "USD", "AUD"},
+		{"A$250", "USD", "AUD"},
+		{"C$250", "USD", "CAD"},
+		{"US$250", "AUD", "USD"},
+		{"NZ$250", "USD", "NZD"},
+		{"HK$250", "USD", "HKD"},
+		{"S$250", "USD", "SGD"},
+		{"€250", "USD", "EUR"},
+		{"£250", "USD", "GBP"},
+		{"₹250", "USD", "INR"},
+		// A code beats the symbol.
+		{"CA$250 USD", "USD", "USD"},
+		{"$30-250 AUD", "USD", "AUD"},
+		// A bare "$" falls back to --default-currency.
+		{"$250", "USD", "USD"},
+		{"$250", "CAD", "CAD"},
+		{"250", "USD", ""},
+	}
+	for _, tt := range tests {
+		defaultCurrency = tt.defaultCurrency
+		if got := resolveCurrency(tt.text); got != tt.want {
+			t.Errorf("resolveCurrency(%q) with default %s = %q; want %q", tt.text, tt.defaultCurrency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// cpuProfile is the open --cpuprofile file while profiling; nil otherwise.
+var cpuProfile *os.File
+
+// startProfiling starts the --cpuprofile CPU profile, if asked for.
+func startProfiling() {
+	if cpuProfileFile == "" {
+		return
+	}
+	f, err := os.Create(cpuProfileFile)
+	if err != nil {
+		log.Printf("Warning: could not create CPU profile: %v", err)
+		return
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		log.Printf("Warning: could not start CPU profile: %v", err)
+		return
+	}
+	cpuProfile = f
+}
+
+// stopProfiling stops the CPU profile and writes the --memprofile heap
+// profile. It runs at most once, so fatalf can call it before exiting.
+func stopProfiling() {
+	if cpuProfile != nil {
+		pprof.StopCPUProfile()
+		cpuProfile.Close()
+		fmt.Println("Generated:", cpuProfile.Name())
+		cpuProfile = nil
+	}
+	if memProfileFile != "" {
+		path := memProfileFile
+		memProfileFile = ""
+		f, err := os.Create(path)
+		if err != nil {
+			log.Printf("Warning: could not create heap profile: %v", err)
+			return
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			log.Printf("Warning: could not write heap profile: %v", err)
+			return
+		}
+		fmt.Println("Generated:", path)
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// publishTimeout bounds connecting to the broker and each write to it.
+const publishTimeout = 10 * time.Second
+
+// projectPublisher sends each scraped project as a JSON message to a Redis
+// channel or NATS subject as soon as its card is parsed, for --publish.
+// Projects are published once per run, however many pages or queries find
+// them. After the first failure it stops publishing and says so once.
+type projectPublisher struct {
+	subject string
+	broker  broker
+
+	mu     sync.Mutex
+	sent   map[string]bool
+	failed bool
+	count  int
+}
+
+// broker is the connection to one kind of message broker.
+type broker interface {
+	publish(subject string, msg []byte) error
+	close() error
+}
+
+// publisher is the --publish publisher of the current run; nil when off.
+var publisher *projectPublisher
+
+// openPublisher connects to the broker named by rawURL, a redis:// or
+// nats:// URL. subject is the channel or subject to publish on.
+func openPublisher(rawURL, subject string) (*projectPublisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var b broker
+	switch u.Scheme {
+	case "redis":
+		b, err = dialRedis(u)
+	case "nats":
+		b, err = dialNATS(u)
+	default:
+		return nil, fmt.Errorf("unsupported --publish scheme %q (expected redis:// or nats://)", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &projectPublisher{subject: subject, broker: b, sent: make(map[string]bool)}, nil
+}
+
+// onProject publishes p and keeps it; it fits Options.OnProject. Publishing
+// errors never fail the scrape.
+func (pp *projectPublisher) onProject(p Project) (bool, error) {
+	if pp == nil {
+		return true, nil
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	key := projectKey(p)
+	if pp.failed || pp.sent[key] {
+		return true, nil
+	}
+	msg, err := json.Marshal(outputProject(p))
+	if err == nil {
+		err = pp.broker.publish(pp.subject, msg)
+	}
+	if err != nil {
+		pp.failed = true
+		log.Printf("Warning: publishing to --publish failed, not publishing any more projects: %v", err)
+		return true, nil
+	}
+	pp.sent[key] = true
+	pp.count++
+	return true, nil
+}
+
+// close flushes and closes the connection, reporting how many projects were
+// published.
+func (pp *projectPublisher) close() {
+	if pp == nil {
+		return
+	}
+	if err := pp.broker.close(); err != nil && !pp.failed {
+		log.Printf("Warning: publishing to --publish may have failed: %v", err)
+		return
+	}
+	fmt.Printf("Published %d projects to %s.\n", pp.count, pp.subject)
+}
+
+// redisBroker publishes with Redis's PUBLISH command.
+type redisBroker struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialRedis(u *url.URL) (*redisBroker, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	conn, err := net.DialTimeout("tcp", host, publishTimeout)
+	if err != nil {
+		return nil, err
+	}
+	b := &redisBroker{conn: conn, r: bufio.NewReader(conn)}
+	if password, ok := u.User.Password(); ok {
+		args := []string{"AUTH", password}
+		if name := u.User.Username(); name != "" {
+			args = []string{"AUTH", name, password}
+		}
+		if _, err := b.command(args...); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("redis AUTH: %w", err)
+		}
+	}
+	return b, nil
+}
+
+// command sends one command as a RESP array and returns the reply line.
+func (b *redisBroker) command(args ...string) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	b.conn.SetDeadline(time.Now().Add(publishTimeout))
+	if _, err := b.conn.Write([]byte(sb.String())); err != nil {
+		return "", err
+	}
+	line, err := b.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return "", errors.New(line[1:])
+	}
+	return line, nil
+}
+
+func (b *redisBroker) publish(subject string, msg []byte) error {
+	_, err := b.command("PUBLISH", subject, string(msg))
+	return err
+}
+
+func (b *redisBroker) close() error {
+	return b.conn.Close()
+}
+
+// natsBroker publishes with the NATS text protocol's PUB.
+type natsBroker struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func dialNATS(u *url.URL) (*natsBroker, error) {
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	conn, err := net.DialTimeout("tcp", host, publishTimeout)
+	if err != nil {
+		return nil, err
+	}
+	b := &natsBroker{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	conn.SetDeadline(time.Now().Add(publishTimeout))
+	if info, err := b.r.ReadString('\n'); err != nil || !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return nil, fmt.Errorf("nats: unexpected greeting %q: %v", strings.TrimSpace(info), err)
+	}
+	opts := map[string]any{"verbose": false, "pedantic": false, "name": "flparser", "lang": "go"}
+	if name := u.User.Username(); name != "" {
+		if password, ok := u.User.Password(); ok {
+			opts["user"], opts["pass"] = name, password
+		} else {
+			opts["auth_token"] = name
+		}
+	}
+	connect, _ := json.Marshal(opts)
+	fmt.Fprintf(b.w, "CONNECT %s\r\n", connect)
+	if err := b.ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("nats CONNECT: %w", err)
+	}
+	return b, nil
+}
+
+// ping flushes what has been written and waits for the server's PONG, which
+// comes after any error caused by the earlier commands.
+func (b *natsBroker) ping() error {
+	b.conn.SetDeadline(time.Now().Add(publishTimeout))
+	b.w.WriteString("PING\r\n")
+	if err := b.w.Flush(); err != nil {
+		return err
+	}
+	for {
+		line, err := b.r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			b.w.WriteString("PONG\r\n")
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.Trim(strings.TrimPrefix(line, "-ERR "), "'"))
+		}
+	}
+}
+
+func (b *natsBroker) publish(subject string, msg []byte) error {
+	b.conn.SetDeadline(time.Now().Add(publishTimeout))
+	b.w.WriteString("PUB " + subject + " " + strconv.Itoa(len(msg)) + "\r\n")
+	b.w.Write(msg)
+	b.w.WriteString("\r\n")
+	return b.w.Flush()
+}
+
+func (b *natsBroker) close() error {
+	err := b.ping()
+	b.conn.Close()
+	return err
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queryResult is the outcome of scraping a single search query. result
+// combines every page scraped for it.
+type queryResult struct {
+	query  string
+	params map[string]string
+	result *PageResult
+	// interrupted is set when the run was interrupted before every page was
+	// fetched; result holds the pages fetched until then.
+	interrupted bool
+	// pages counts the pages fetched or restored before any error.
+	pages int
+	err   error
+}
+
+// readQueryFile reads one search query per line, skipping blank lines and
+// lines starting with '#'.
+func readQueryFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var queries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s contains no queries", path)
+	}
+	return queries, nil
+}
+
+// scrapeQueries runs every query with at most --query-concurrency queries in
+// flight, fetching each query's pages in order. Pages already in cp are
+// reused rather than fetched. Results are returned in the same order as
+// queries, however the requests happen to complete. Requests are spaced out
+// by throttle. Once ctx is cancelled, or
+// --failure-threshold requests in a row have failed, no more pages are
+// fetched and the affected queries are marked interrupted.
+func scrapeQueries(ctx context.Context, client *http.Client, queries []string, pages []int, cp *checkpoint) []queryResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	circuit = newCircuitBreaker(failureThreshold, cancel)
+	throttle = newRequestThrottle()
+
+	results := make([]queryResult, len(queries))
+	sem := make(chan struct{}, max(queryWorkers, 1))
+	var wg sync.WaitGroup
+	for i, query := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			_, params := buildURL(query, pages[0])
+			if useAPI {
+				params["endpoint"] = "api"
+			}
+			if len(pages) > 1 {
+				delete(params, "page")
+				params["pages"] = fmt.Sprintf("%d-%d", pages[0], pages[len(pages)-1])
+			}
+			total := &PageResult{}
+			results[i] = queryResult{query: query, params: params, result: total}
+
+			label := ""
+			if len(queries) > 1 {
+				label = fmt.Sprintf(" for %q", query)
+			}
+			last := 0
+			for n, page := range pages {
+				// The first page says how many pages the search has; past that,
+				// or after a page with no cards, there's nothing left to fetch.
+				if n > 0 && total.TotalPages > 0 && page > total.TotalPages {
+					fmt.Printf("Reached last page%s at %d; skipping pages %d-%d.\n", label, total.TotalPages, page, pages[len(pages)-1])
+					return
+				}
+				result := cp.lookup(query, page)
+				if result == nil && ctx.Err() != nil {
+					results[i].interrupted = true
+					return
+				}
+				if result == nil {
+					targetURL, _ := buildURL(query, page)
+					if useAPI {
+						targetURL = buildAPIURL(query, page)
+					}
+					opts := Options{Context: ctx, URL: targetURL, API: useAPI, Client: client, Strict: strict, KeepPartial: keepPartial, IncludeSponsored: includeSponsored, Cookie: cookie, Locale: locale, OnProject: publisher.onProject, DumpDir: dumpOnError}
+					var err error
+					result, err = fetchPage(ctx, opts)
+					// A page with no cards that isn't the no-results page is
+					// sometimes a rendering hiccup that a second fetch fixes.
+					if retryEmpty && ctx.Err() == nil && (errors.Is(err, ErrNoCards) || err == nil && result.Cards == 0 && !result.NoResults) {
+						log.Printf("Page %d%s came back empty without a no-results notice; retrying once in %s", page, label, retryEmptyDelay)
+						if sleepCtx(ctx, retryEmptyDelay) == nil {
+							result, err = fetchPage(ctx, opts)
+						}
+					}
+					if err != nil && ctx.Err() != nil {
+						results[i].interrupted = true
+						return
+					}
+					circuit.record(err)
+					if err != nil {
+						if len(pages) > 1 {
+							err = fmt.Errorf("page %d: %w", page, err)
+						}
+						results[i].err = err
+						return
+					}
+					if err := cp.record(query, page, result); err != nil {
+						log.Printf("Warning: could not save checkpoint: %v", err)
+					}
+				}
+				for j := range result.Projects {
+					result.Projects[j].Page = page
+				}
+				addPage(total, result, n == 0)
+				results[i].pages++
+				if result.Cards == 0 && n < len(pages)-1 {
+					if last > 0 {
+						fmt.Printf("Reached last page%s at %d; page %d has no projects.\n", label, last, page)
+					}
+					return
+				}
+				last = page
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// retryEmptyDelay is how long --retry-empty waits before fetching an empty
+// page again.
+const retryEmptyDelay = 2 * time.Second
+
+// fetchPage scrapes one page through the throttle, retrying blocked requests
+// as the throttle allows.
+func fetchPage(ctx context.Context, opts Options) (*PageResult, error) {
+	for attempt := 0; ; attempt++ {
+		if err := throttle.wait(ctx); err != nil {
+			return nil, err
+		}
+		result, err := Scrape(opts)
+		if !throttle.record(err) || attempt == throttleRetries {
+			return result, err
+		}
+	}
+}
+
+// sleepCtx waits for d, or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// addPage folds one page's result into a query's running total. The
+// no-results flag and search totals are taken from the first page.
+func addPage(total, page *PageResult, first bool) {
+	total.Projects = append(total.Projects, page.Projects...)
+	total.Cards += page.Cards
+	total.Skipped += page.Skipped
+	total.Sponsored += page.Sponsored
+	if first {
+		total.NoResults = page.NoResults
+		total.TotalResults = page.TotalResults
+		total.TotalPages = page.TotalPages
+	}
+}
+
+// mergeQueryResults concatenates the projects of each successful query in
+// query order, then by page and position on the page, dropping duplicates
+// found earlier; the output is the same however the concurrent requests
+// completed. With more than one query, each project is tagged with the query
+// that found it and the returned parameters list every query instead of a
+// single "q". Result totals are only reported for a single query, since
+// overlapping queries can't be summed meaningfully.
+func mergeQueryResults(results []queryResult) OutputData {
+	multi := len(results) > 1
+	seen := make(map[string]bool)
+	var projects []Project
+	failed := 0
+	interrupted := false
+
+	for _, qr := range results {
+		summary.Pages += qr.pages
+		if qr.interrupted {
+			interrupted = true
+		}
+		label := ""
+		if multi {
+			label = fmt.Sprintf(" for %q", qr.query)
+		}
+		if qr.err != nil {
+			if (!multi || errors.Is(qr.err, ErrNoCards)) && !circuit.isOpen() {
+				if checkpointFile != "" {
+					log.Printf("Progress saved to %s; re-run with --resume to continue.", checkpointFile)
+				}
+				fatalf("Error scraping: %v", qr.err)
+			}
+			log.Printf("Error scraping%s: %v", label, qr.err)
+			summary.Status = statusPartial
+			failed++
+			continue
+		}
+		if qr.result.Skipped > 0 {
+			verb := "skipped"
+			if keepPartial {
+				verb = "kept"
+			}
+			log.Printf("Warning: %s %d malformed cards%s; the page layout may be drifting", verb, qr.result.Skipped, label)
+		}
+		if qr.result.Sponsored > 0 {
+			fmt.Printf("Excluded %d sponsored cards%s.\n", qr.result.Sponsored, label)
+		}
+		if qr.result.Cards == 0 && !qr.interrupted {
+			if qr.result.NoResults {
+				fmt.Printf("Search%s returned no matching projects.\n", label)
+			} else {
+				log.Printf("Warning: no projects were parsed%s and the page has no \"no results\" notice; the page layout may have changed.", label)
+			}
+		}
+		// Pages are already collected in order, but sorting makes the
+		// ordering an explicit guarantee rather than an accident of how the
+		// workers were scheduled.
+		sort.SliceStable(qr.result.Projects, func(i, j int) bool {
+			a, b := qr.result.Projects[i], qr.result.Projects[j]
+			if a.Page != b.Page {
+				return a.Page < b.Page
+			}
+			return a.Position < b.Position
+		})
+		for _, p := range qr.result.Projects {
+			key := projectKey(p)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			if multi {
+				p.Query = qr.query
+			}
+			projects = append(projects, p)
+		}
+	}
+	if failed == len(results) && !circuit.isOpen() {
+		fatalf("Error scraping: all %d queries failed", failed)
+	}
+
+	params := results[0].params
+	if multi {
+		params = make(map[string]string, len(results[0].params))
+		for k, v := range results[0].params {
+			if k != "q" {
+				params[k] = v
+			}
+		}
+		queries := make([]string, len(results))
+		for i, qr := range results {
+			queries[i] = qr.query
+		}
+		params["queries"] = strings.Join(queries, "; ")
+	}
+
+	switch {
+	case circuit.isOpen():
+		params["partial"] = "circuit_open"
+		fmt.Printf("Writing the %d projects collected before the circuit opened.\n", len(projects))
+	case interrupted:
+		params["partial"] = "interrupted"
+		summary.Status = statusPartial
+		fmt.Printf("Run interrupted; writing the %d projects collected so far.\n", len(projects))
+	}
+
+	data := OutputData{Parameters: params, Projects: projects}
+	if !multi {
+		data.TotalResults = results[0].result.TotalResults
+		data.TotalPages = results[0].result.TotalPages
+	}
+	return data
+}
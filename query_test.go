@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rewriteTransport sends every request to target, whatever host it names,
+// so code that builds freelancer.com URLs can be pointed at a test server.
+// Like http.Transport it refuses URLs without a scheme.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (rt rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !req.URL.IsAbs() {
+		return nil, fmt.Errorf("unsupported protocol scheme %q", req.URL.Scheme)
+	}
+	req = req.Clone(req.Context())
+	req.URL.Scheme = rt.target.Scheme
+	req.URL.Host = rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// searchPageServer serves a synthetic three-card search page for any query
+// and page after a random delay of up to 20ms. The first card of every
+// query's first page is the same project, so merging has duplicates to drop.
+func searchPageServer(t *testing.T) *http.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Duration(rand.IntN(20)) * time.Millisecond)
+		q := r.URL.Query().Get("q")
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><body><div id="project-list">`)
+		for n := 1; n <= 3; n++ {
+			slug := fmt.Sprintf("%s-%d-%d", q, page, n)
+			if page == 1 && n == 1 {
+				slug = "shared"
+			}
+			fmt.Fprintf(w, `<div class="JobSearchCard-item"><div class="JobSearchCard-primary-heading"><a class="JobSearchCard-primary-heading-link" href="/projects/php/%s">%s</a></div></div>`, slug, slug)
+		}
+		fmt.Fprint(w, `</div></body></html>`)
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	return &http.Client{Transport: rewriteTransport{target: target}}
+}
+
+func TestScrapeQueriesDeterministicOrder(t *testing.T) {
+	defer func(old int) { queryWorkers = old }(queryWorkers)
+	queryWorkers = 8
+	client := searchPageServer(t)
+
+	queries := []string{"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel"}
+	pages := []int{1, 2, 3}
+	var want []string
+	for _, q := range queries {
+		for _, page := range pages {
+			for n := 1; n <= 3; n++ {
+				if page == 1 && n == 1 {
+					if q == queries[0] {
+						want = append(want, "alpha:shared")
+					}
+					continue
+				}
+				want = append(want, fmt.Sprintf("%s:%s-%d-%d", q, q, page, n))
+			}
+		}
+	}
+
+	for run := 0; run < 5; run++ {
+		results := scrapeQueries(context.Background(), client, queries, pages, nil)
+		data := mergeQueryResults(results)
+		got := make([]string, len(data.Projects))
+		for i, p := range data.Projects {
+			got[i] = p.Query + ":" + p.Title
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("run %d: merged order differs\ngot:  %s\nwant: %s", run, strings.Join(got, " "), strings.Join(want, " "))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// usdRates are rough US dollar values of one unit of the currencies
+// Freelancer lists budgets in, used only to rank budgets across currencies
+// for --sort-by budget-normalized. They are approximate and not updated
+// live; --rates-file overrides them.
+var usdRates = map[string]float64{
+	"USD": 1,
+	"EUR": 1.08,
+	"GBP": 1.27,
+	"AUD": 0.66,
+	"CAD": 0.73,
+	"NZD": 0.60,
+	"SGD": 0.74,
+	"HKD": 0.13,
+	"INR": 0.012,
+	"PHP": 0.018,
+	"PKR": 0.0036,
+	"IDR": 0.000063,
+	"MYR": 0.22,
+	"JPY": 0.0067,
+	"CNY": 0.14,
+	"BRL": 0.19,
+	"MXN": 0.055,
+	"ZAR": 0.054,
+	"SEK": 0.095,
+	"CHF": 1.12,
+	"PLN": 0.25,
+	"BDT": 0.0085,
+	"NGN": 0.00065,
+	"KES": 0.0077,
+	"AED": 0.27,
+}
+
+// loadRates merges the rates in path, a JSON object of ISO currency codes to
+// US dollar values such as {"EUR": 1.1}, over usdRates.
+func loadRates(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var rates map[string]float64
+	if err := json.Unmarshal(raw, &rates); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for code, rate := range rates {
+		if rate <= 0 {
+			return fmt.Errorf("%s: rate for %s must be positive", path, code)
+		}
+		usdRates[strings.ToUpper(code)] = rate
+	}
+	return nil
+}
+
+// normalizedBudget returns p's maximum budget in approximate US dollars, or
+// -1 when it has no budget or its currency has no known rate, so that it
+// ranks below every converted one.
+func normalizedBudget(p Project) float64 {
+	rate, ok := usdRates[p.Currency]
+	if !ok || p.BudgetMax <= 0 {
+		return -1
+	}
+	return p.BudgetMax * rate
+}
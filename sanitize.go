@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags are the elements sanitizeHTML keeps, each with the attributes
+// it may carry. Anything else is unwrapped to its contents, except for
+// droppedTags, which are removed along with their contents.
+var allowedTags = map[string][]string{
+	"a": {"href"}, "b": nil, "strong": nil, "i": nil, "em": nil, "u": nil,
+	"p": nil, "br": nil, "ul": nil, "ol": nil, "li": nil,
+	"code": nil, "pre": nil, "blockquote": nil,
+}
+
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true,
+	"form": true, "input": true, "button": true, "textarea": true, "select": true,
+	"noscript": true, "template": true, "svg": true, "math": true,
+}
+
+// sanitizeHTML renders the children of n keeping only whitelisted markup, so
+// a description's formatting and links survive without letting a listing
+// inject scripts into a report. Links must be http(s) or site-relative; the
+// latter are resolved like project links.
+func sanitizeHTML(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(&sb, c)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func sanitizeNode(sb *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(html.EscapeString(n.Data))
+		return
+	case html.ElementNode:
+	default:
+		return
+	}
+	tag := strings.ToLower(n.Data)
+	if droppedTags[tag] {
+		return
+	}
+	attrs, allowed := allowedTags[tag]
+	if allowed {
+		sb.WriteString("<" + tag)
+		for _, a := range n.Attr {
+			if !slices.Contains(attrs, strings.ToLower(a.Key)) {
+				continue
+			}
+			v := a.Val
+			if a.Key == "href" {
+				if v = safeHref(v); v == "" {
+					continue
+				}
+			}
+			sb.WriteString(" " + a.Key + `="` + html.EscapeString(v) + `"`)
+		}
+		if tag == "a" {
+			sb.WriteString(` rel="nofollow noopener"`)
+		}
+		sb.WriteString(">")
+		if tag == "br" {
+			return
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sanitizeNode(sb, c)
+	}
+	if allowed {
+		sb.WriteString("</" + tag + ">")
+	}
+}
+
+// safeHref returns href resolved for output, or "" when it isn't an http(s)
+// or relative link (javascript:, data: and the like).
+func safeHref(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "":
+		if u.Host != "" {
+			return ""
+		}
+		return resolveLink(u.String())
+	case "http", "https":
+		return u.String()
+	}
+	return ""
+}
@@ -0,0 +1,814 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// PageResult is everything parsed from a single search results page.
+type PageResult struct {
+	Projects []Project
+	// Cards is how many project cards matched on the page, including any
+	// that OnProject dropped.
+	Cards int
+	// NoResults is set when the page carries Freelancer's explicit
+	// "no projects found" notice, as opposed to simply yielding no cards.
+	NoResults bool
+	// Skipped counts malformed cards (no title or link). They're dropped
+	// unless Options.KeepPartial is set.
+	Skipped int
+	// Sponsored counts promotional cards that were dropped because they
+	// aren't real listings (see isSponsored).
+	Sponsored int
+	// TotalResults and TotalPages describe the whole search as reported by
+	// the page; both are zero when the page doesn't show them.
+	TotalResults int
+	TotalPages   int
+}
+
+// userAgent is sent with every page request; Freelancer serves a reduced page
+// to clients it doesn't recognise as browsers.
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+
+// resultsPerPage is how many cards Freelancer shows per search page.
+const resultsPerPage = 20
+
+// noResultsSelector matches the notice Freelancer renders in place of the
+// card list when a search matches nothing.
+const noResultsSelector = ".JobSearchCard-empty, .search-result-empty, .no-results"
+
+// ErrNoCards is returned in strict mode when a page has no project cards
+// and isn't Freelancer's no-results page, which usually means the markup
+// changed and the selectors need updating.
+var ErrNoCards = errors.New("no project cards matched .JobSearchCard-item and the page isn't a no-results page; the page layout may have changed")
+
+// ErrParse is returned when a response can't be parsed as a results page at
+// all, such as a JSON or plain-text error served with status 200.
+var ErrParse = errors.New("response couldn't be parsed as HTML")
+
+// parseError returns ErrParse wrapped with the response's content type and
+// the start of its body, saving the whole body in opts.DumpDir if set.
+func parseError(opts Options, raw []byte, contentType string, cause error) error {
+	snippet := []rune(cleanText(string(raw)))
+	if len(snippet) > 120 {
+		snippet = append(snippet[:120], '…')
+	}
+	err := fmt.Errorf("%w (content type %q, body starts %q)", ErrParse, contentType, string(snippet))
+	if cause != nil {
+		err = fmt.Errorf("%w: %v", err, cause)
+	}
+	if opts.DumpDir != "" {
+		if path, dumpErr := dumpBody(opts.DumpDir, raw); dumpErr != nil {
+			log.Printf("Warning: could not save the unparsable response: %v", dumpErr)
+		} else {
+			err = fmt.Errorf("%w; response saved to %s", err, path)
+		}
+	}
+	return err
+}
+
+// dumpBody saves a response body in dir under a new name and returns it.
+func dumpBody(dir string, raw []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(dir, "flparser-error-"+time.Now().Format("20060102-150405")+"-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(raw); err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// ErrChallenge is returned when Freelancer answers with a Cloudflare
+// challenge page instead of results, which means the requests look automated.
+var ErrChallenge = errors.New("blocked by a Cloudflare challenge page; pass the cookies from a browser session with --cookie, route requests through a proxy with HTTPS_PROXY, or scrape fewer pages at a time")
+
+// ErrRateLimited is returned when Freelancer answers 429 Too Many Requests.
+var ErrRateLimited = errors.New("rate limited by Freelancer; slow down with --delay or --throttle-on-block")
+
+// challengeMarkers are strings found in Cloudflare's challenge and block
+// pages.
+var challengeMarkers = []string{"cf-chl", "challenge-platform", "cf_chl_opt", "Just a moment...", "Attention Required! | Cloudflare", "cf-error-details"}
+
+// checkChallenge returns ErrRateLimited for a 429, and ErrChallenge, wrapped
+// with the status, when resp is a Cloudflare challenge: a 403 or 503 from
+// Cloudflare whose body carries one of challengeMarkers. It reads at most the
+// first 64 KiB of the body, so only call it on responses that are going to be
+// rejected anyway.
+func checkChallenge(resp *http.Response) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("status %d: %w", resp.StatusCode, ErrRateLimited)
+	}
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusServiceUnavailable {
+		return nil
+	}
+	fromCloudflare := resp.Header.Get("CF-Ray") != "" || strings.EqualFold(resp.Header.Get("Server"), "cloudflare")
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	for _, marker := range challengeMarkers {
+		if bytes.Contains(body, []byte(marker)) {
+			return fmt.Errorf("status %d: %w", resp.StatusCode, ErrChallenge)
+		}
+	}
+	if fromCloudflare && resp.Header.Get("Cf-Mitigated") == "challenge" {
+		return fmt.Errorf("status %d: %w", resp.StatusCode, ErrChallenge)
+	}
+	return nil
+}
+
+// newHTTPClient returns the client used for talking to Freelancer, with its
+// transport tuned by the --max-idle-conns, --disable-keepalive and
+// --disable-http2 flags. With --har-file, requests go through harLog.
+func newHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdleConns
+	transport.MaxIdleConnsPerHost = maxIdleConns
+	transport.DisableKeepAlives = disableKeepAlive
+	if disableHTTP2 {
+		// A non-nil, empty TLSNextProto is the documented way to turn off
+		// HTTP/2 and stay on HTTP/1.1.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	var rt http.RoundTripper = transport
+	if harFile != "" {
+		if harLog == nil {
+			harLog = newHARRecorder(transport, harFile, !harUnredacted)
+		}
+		rt = harLog
+	}
+	return &http.Client{Timeout: 30 * time.Second, Transport: rt}
+}
+
+// Values of Project.PriceType.
+const (
+	priceTypeHourly  = "hourly"
+	priceTypeFixed   = "fixed"
+	priceTypeUnknown = "unknown"
+)
+
+// Options configures a single Scrape call.
+type Options struct {
+	// URL is the search results page to fetch, typically built by buildURL.
+	URL string
+	// Client performs the request. When nil, newHTTPClient is used; pass a
+	// shared client to reuse connections across pages, or a custom one to
+	// plug in a different transport.
+	Client *http.Client
+	// KeepPartial keeps cards that are missing a title or link instead of
+	// skipping them.
+	KeepPartial bool
+	// IncludeSponsored keeps promotional cards instead of dropping them.
+	IncludeSponsored bool
+	// Strict makes Scrape fail with ErrNoCards when the page has no project
+	// cards and isn't the explicit no-results page.
+	Strict bool
+	// Cookie, if set, is sent as the Cookie header, e.g. to reuse a browser
+	// session that has passed a Cloudflare challenge.
+	Cookie string
+	// Locale, if set, is sent as the Accept-Language header. The parser
+	// expects English text in places, so other locales may parse worse.
+	Locale string
+	// API marks URL as a projects API URL (see buildAPIURL) rather than an
+	// HTML search page.
+	API bool
+	// OnProject, if set, is called for every parsed card in page order.
+	// Returning keep=false drops the project; a non-nil error aborts the
+	// scrape and is returned from Scrape.
+	OnProject func(Project) (keep bool, err error)
+	// Context, if set, cancels the request when it's done.
+	Context context.Context
+	// DumpDir, if set, is where responses that fail with ErrParse are
+	// saved for inspection.
+	DumpDir string
+}
+
+func (o Options) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// Scrape fetches and parses one Freelancer search results page.
+func Scrape(opts Options) (*PageResult, error) {
+	client := opts.Client
+	if client == nil {
+		client = newHTTPClient()
+	}
+	if opts.API {
+		return scrapeAPI(opts, client)
+	}
+	req, err := http.NewRequestWithContext(opts.context(), "GET", opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if opts.Cookie != "" {
+		req.Header.Set("Cookie", opts.Cookie)
+	}
+	if opts.Locale != "" {
+		req.Header.Set("Accept-Language", opts.Locale)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		if err := checkChallenge(resp); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// A 200 that isn't HTML is an error page of some kind, not a changed
+	// layout, so it's reported as such rather than as "no cards".
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(raw)
+	}
+	if !strings.Contains(strings.ToLower(contentType), "html") {
+		return nil, parseError(opts, raw, contentType, nil)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, parseError(opts, raw, contentType, err)
+	}
+
+	result := &PageResult{}
+	var projects []Project
+	var hookErr error
+
+	cards := doc.Find(".JobSearchCard-item")
+	result.Cards = cards.Length()
+	cards.EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if !opts.IncludeSponsored && isSponsored(s) {
+			result.Sponsored++
+			return true
+		}
+		p := parseCard(s)
+		p.Position = i + 1
+		var missing []string
+		if p.Title == "" {
+			missing = append(missing, "title")
+		}
+		if p.Link == "" {
+			missing = append(missing, "link")
+		}
+		if len(missing) > 0 {
+			result.Skipped++
+			if !opts.KeepPartial {
+				log.Printf("Warning: card %d has no %s; skipping it", i+1, strings.Join(missing, " or "))
+				return true
+			}
+			log.Printf("Warning: card %d has no %s; keeping it as partial", i+1, strings.Join(missing, " or "))
+		}
+
+		if opts.OnProject != nil {
+			keep, err := opts.OnProject(p)
+			if err != nil {
+				hookErr = err
+				return false
+			}
+			if !keep {
+				return true
+			}
+		}
+		projects = append(projects, p)
+		return true
+	})
+	if hookErr != nil {
+		return nil, hookErr
+	}
+
+	result.Projects = projects
+	result.TotalResults, result.TotalPages = parsePagination(doc)
+	if result.Cards == 0 {
+		result.NoResults = doc.Find(noResultsSelector).Length() > 0 ||
+			strings.Contains(doc.Find("body").Text(), "No projects found")
+		if opts.Strict && !result.NoResults {
+			return nil, ErrNoCards
+		}
+	}
+	return result, nil
+}
+
+// sealedSelector matches the badges Freelancer puts on sealed-bid cards.
+const sealedSelector = ".PromotionTag--sealed, .JobSearchCard-primary-promotion, [data-upgrade]"
+
+// isSealed reports whether a card is marked as a sealed-bid project.
+func isSealed(s *goquery.Selection) bool {
+	sealed := false
+	s.Find(sealedSelector).EachWithBreak(func(_ int, badge *goquery.Selection) bool {
+		v, _ := badge.Attr("data-upgrade")
+		sealed = badge.HasClass("PromotionTag--sealed") || strings.EqualFold(v, "sealed") || strings.EqualFold(cleanText(badge.Text()), "sealed")
+		return !sealed
+	})
+	return sealed
+}
+
+// sponsoredSelector matches the markers Freelancer puts on promotional and
+// "recommended" cards mixed into the results.
+const sponsoredSelector = ".JobSearchCard-item--promoted, .JobSearchCard-item--sponsored, .JobSearchCard-sponsored, .JobSearchCard-promoted, [data-sponsored], [data-promoted]"
+
+// isSponsored reports whether a .JobSearchCard-item is an ad rather than a
+// project: it's marked as sponsored, or it links somewhere other than a
+// project page and has none of a listing's price, bids or time left.
+func isSponsored(s *goquery.Selection) bool {
+	if s.Is(sponsoredSelector) || s.Find(sponsoredSelector).Length() > 0 {
+		return true
+	}
+	href, _ := s.Find(".JobSearchCard-primary-heading a").Attr("href")
+	if href == "" || strings.Contains(href, "/projects/") || strings.Contains(href, "/contest/") {
+		return false
+	}
+	return cleanText(s.Find(".JobSearchCard-secondary-price, .JobSearchCard-secondary-entry, .JobSearchCard-primary-heading-days").Text()) == ""
+}
+
+// parseCard extracts a Project from a single .JobSearchCard-item.
+func parseCard(s *goquery.Selection) Project {
+	titleNode := s.Find(".JobSearchCard-primary-heading a")
+	title := cleanText(titleNode.Text())
+
+	linkHref, exists := s.Find("a.JobSearchCard-ctas-btn").Attr("href")
+	if !exists {
+		linkHref, _ = titleNode.Attr("href")
+	}
+	linkHref = resolveLink(linkHref)
+	canonical := canonicalLink(linkHref)
+
+	descNode := s.Find(".JobSearchCard-primary-description")
+	desc := descriptionFromNode(descNode)
+	var descHTML string
+	if keepHTML && descNode.Length() > 0 {
+		descHTML = sanitizeHTML(descNode.Get(0))
+	}
+
+	var skillTags []string
+	s.Find(".JobSearchCard-primary-tagsLink").Each(func(i int, tag *goquery.Selection) {
+		if name := cleanText(tag.Text()); name != "" {
+			skillTags = append(skillTags, name)
+		}
+	})
+
+	timeLeft := cleanText(s.Find(".JobSearchCard-primary-heading-days").Text())
+	closed := isClosedTimeLeft(timeLeft) || s.Is(closedSelector) || s.Find(closedSelector).Length() > 0
+
+	// The "Avg Bid" label is translated on localized pages, so prefer the
+	// element that holds it over matching its English text.
+	priceNode := s.Find(".JobSearchCard-secondary-price")
+	priceFull := priceNode.Text()
+	avgLabel := cleanText(priceNode.Find(".JobSearchCard-secondary-avgBid").Text())
+
+	budget := cleanText(priceFull)
+	if avgLabel != "" {
+		budget = strings.Replace(budget, avgLabel, "", 1)
+	}
+	budget = strings.ReplaceAll(budget, "Avg Bid", "")
+	budget = cleanText(budget)
+
+	// Contests listed among the results count entries rather than bids in
+	// the same element; they're kept apart so neither number is mislabelled.
+	bids := cleanText(s.Find(".JobSearchCard-secondary-entry").Text())
+	var entries int
+	if isEntryCount(bids) || (strings.Contains(linkHref, "/contest/") && bids != "") {
+		entries = parseCount(bids)
+		bids = ""
+	}
+
+	// The price block shows the average bid, labelled as such, once a project
+	// has bids, and then it replaces the posted budget rather than adding to
+	// it: the amount is only an average bid, and the budget is unknown.
+	// Sealed projects hide their bids, so the block only ever has the budget.
+	// Without the badge, a sealed project still gives itself away: it has
+	// bids but no average bid.
+	hasAvgBid := avgLabel != "" || strings.Contains(priceFull, "Avg Bid")
+	sealed := isSealed(s) || (!hasAvgBid && parseCount(bids) > 0)
+	var avgBid string
+	var avgBidAmount, budgetMin, budgetMax float64
+	var currency string
+	var perHour bool
+	if !sealed && hasAvgBid {
+		avgBid = budget
+		avgBidAmount, _, currency, perHour = parsePrice(budget)
+		budget = ""
+	} else {
+		budgetMin, budgetMax, currency, perHour = parsePrice(budget)
+	}
+
+	// Text without an amount, such as "N/A", says nothing about the type.
+	priceType := priceTypeUnknown
+	if budgetMax > 0 || avgBidAmount > 0 {
+		priceType = priceTypeFixed
+		if perHour {
+			priceType = priceTypeHourly
+		}
+	}
+
+	// Cards mark attached files with an icon or a data attribute holding the
+	// count; the project page, fetched with --full-description, lists them.
+	var attachments int
+	if v, ok := s.Find("[data-attachments]").First().Attr("data-attachments"); ok {
+		attachments = parseCount(v)
+	} else {
+		attachments = s.Find(".JobSearchCard-primary-attachment, .JobSearchCard-attachment").Length()
+	}
+
+	// Anonymous and recruiter listings carry no employer block at all;
+	// hasEmployer records whether any of it was there, so a zero rating or
+	// review count can be told apart from a missing one.
+	var hasEmployer bool
+	var rating float64
+	if v, ok := s.Find("[data-star_rating]").First().Attr("data-star_rating"); ok {
+		hasEmployer = true
+		rating, _ = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	var reviews int
+	if v, ok := s.Find("[data-review_count]").First().Attr("data-review_count"); ok {
+		hasEmployer = true
+		reviews = parseCount(v)
+	} else if node := s.Find(".Rating-review, .JobSearchCard-secondary-reviews").First(); node.Length() > 0 {
+		hasEmployer = true
+		reviews = parseCount(node.Text())
+	}
+
+	// The employer's country is shown as a flag; depending on the layout the
+	// code is in a data attribute or only the name in the flag's title.
+	var country string
+	if v, ok := s.Find("[data-country-code]").First().Attr("data-country-code"); ok {
+		country = countryCode(v)
+	} else if flag := s.Find(".JobSearchCard-primary-heading-flag, .Flag").First(); flag.Length() > 0 {
+		name, ok := flag.Attr("title")
+		if !ok {
+			name = flag.Text()
+		}
+		country = countryCode(name)
+		// Flag images are also named after the code, e.g. .../flags/us.png.
+		if src, ok := flag.Attr("src"); ok && country == "" {
+			base := path.Base(src)
+			country = countryCode(strings.TrimSuffix(base, path.Ext(base)))
+		}
+	}
+
+	if country != "" {
+		hasEmployer = true
+	}
+
+	posted := s.Find(postedSelector).Map(func(_ int, n *goquery.Selection) string { return n.Text() })
+	postedAt, _ := parsePosted(cleanText(strings.Join(posted, " ")), time.Now().Truncate(time.Second))
+	location, remote := cardLocation(s)
+
+	p := Project{
+		Title:            title,
+		Link:             linkHref,
+		CanonicalLink:    canonical,
+		Description:      desc,
+		DescriptionHTML:  descHTML,
+		Skills:           skillTags,
+		Attachments:      attachments,
+		TimeLeft:         timeLeft,
+		Closed:           closed,
+		PostedAt:         postedAt,
+		Budget:           budget,
+		BudgetMin:        budgetMin,
+		BudgetMax:        budgetMax,
+		Currency:         currency,
+		PriceType:        priceType,
+		AverageBid:       avgBid,
+		AverageBidAmount: avgBidAmount,
+		Sealed:           sealed,
+		BidsCount:        bids,
+		BidsCountNum:     bidsCountNum(bids),
+		Entries:          entries,
+		HasEmployerInfo:  hasEmployer,
+		EmployerRating:   rating,
+		EmployerReviews:  reviews,
+		EmployerCountry:  country,
+		Location:         location,
+		Remote:           remote,
+	}
+	p.ValueScore = valueScore(p)
+	return p
+}
+
+// entryPattern matches contest entry counts such as "12 entries".
+var entryPattern = regexp.MustCompile(`(?i)\bentr(y|ies)\b`)
+
+// isEntryCount reports whether text counts contest entries rather than bids.
+func isEntryCount(text string) bool {
+	return entryPattern.MatchString(text)
+}
+
+// closedSelector matches the badges of cards for projects that have closed.
+const closedSelector = ".JobSearchCard-primary-heading-status--closed, .JobSearchCard-item--closed, [data-status=closed], [data-status=expired]"
+
+// closedTimeLeftPattern matches time-left text of projects that are no
+// longer open for bids: "Ended", "Closed", "Expired" or a zero count.
+var closedTimeLeftPattern = regexp.MustCompile(`(?i)^(ended|closed|expired)\b|^0\s+(seconds?|minutes?|hours?|days?)\s+left`)
+
+// isClosedTimeLeft reports whether a card's time-left text says bidding has
+// ended.
+func isClosedTimeLeft(timeLeft string) bool {
+	return closedTimeLeftPattern.MatchString(strings.TrimSpace(timeLeft))
+}
+
+// locationSelector matches the location line of on-site (local) project
+// cards; most cards have none.
+const locationSelector = ".JobSearchCard-primary-location, .JobSearchCard-location, [data-location]"
+
+// remoteSelector matches the badges of cards explicitly marked as remote.
+const remoteSelector = ".JobSearchCard-remote, .PromotionTag--remote, [data-remote=true]"
+
+// remoteLocationPattern matches location text that means the work is remote
+// rather than naming a place.
+var remoteLocationPattern = regexp.MustCompile(`(?i)^(remote|anywhere|online|work from home|wfh)\b`)
+
+// cardLocation returns the location a card names and whether it's marked as
+// remote. A location line saying "Remote" sets remote rather than being
+// returned as a place.
+func cardLocation(s *goquery.Selection) (location string, remote bool) {
+	remote = s.Find(remoteSelector).Length() > 0
+	node := s.Find(locationSelector).First()
+	location, ok := node.Attr("data-location")
+	if !ok {
+		location = node.Text()
+	}
+	location = cleanText(location)
+	location = strings.TrimSpace(strings.TrimPrefix(location, "Location:"))
+	if remoteLocationPattern.MatchString(location) {
+		return "", true
+	}
+	return location, remote
+}
+
+// valueScore is a fixed-price project's budget midpoint divided by its bids
+// (or a contest's entries) plus one, a rough measure of how lucrative and how
+// contested it is. Hourly projects have no total budget to divide, so they
+// score zero.
+func valueScore(p Project) float64 {
+	if p.PriceType != priceTypeFixed || p.BudgetMax <= 0 {
+		return 0
+	}
+	mid := (p.BudgetMin + p.BudgetMax) / 2
+	competitors := 0
+	if p.BidsCountNum != nil {
+		competitors = *p.BidsCountNum
+	}
+	if p.Entries > 0 {
+		competitors = p.Entries
+	}
+	score := mid / float64(competitors+1)
+	return math.Round(score*100) / 100
+}
+
+// postedSelector matches the card elements that can say when a project was
+// posted: a line of its own, or the heading's time-left text on layouts that
+// put it there. The description isn't searched, so a project whose text
+// mentions "posted 2 days ago" isn't dated by it.
+const postedSelector = ".JobSearchCard-primary-heading-days, .JobSearchCard-primary-heading-posted, .JobSearchCard-posted"
+
+// postedPattern matches relative posting times such as "Posted 3 hours ago"
+// or "posted an hour ago".
+var postedPattern = regexp.MustCompile(`(?i)posted\s+(\d+|an?)\s+(second|minute|hour|day|week|month|year)s?\s+ago`)
+
+// parsePosted turns a card's relative "posted ... ago" text into an absolute
+// time relative to now. It reports false when the text has no posting time.
+func parsePosted(text string, now time.Time) (time.Time, bool) {
+	if strings.Contains(strings.ToLower(text), "posted just now") {
+		return now, true
+	}
+	m := postedPattern.FindStringSubmatch(text)
+	if m == nil {
+		return time.Time{}, false
+	}
+	n := 1
+	if v, err := strconv.Atoi(m[1]); err == nil {
+		n = v
+	}
+	switch strings.ToLower(m[2]) {
+	case "second":
+		return now.Add(-time.Duration(n) * time.Second), true
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "day":
+		return now.AddDate(0, 0, -n), true
+	case "week":
+		return now.AddDate(0, 0, -7*n), true
+	case "month":
+		return now.AddDate(0, -n, 0), true
+	default:
+		return now.AddDate(-n, 0, 0), true
+	}
+}
+
+// parsePagination reads the total result count and the number of pages from
+// the results header and pagination links. The page count falls back to
+// one derived from the total when there are no pagination links.
+func parsePagination(doc *goquery.Document) (total, pages int) {
+	total = parseCount(doc.Find("#total-results").First().Text())
+
+	doc.Find(".Pagination a, .Pagination-item").Each(func(i int, s *goquery.Selection) {
+		if n, err := strconv.Atoi(cleanText(s.Text())); err == nil && n > pages {
+			pages = n
+		}
+	})
+	if pages == 0 && total > 0 {
+		pages = (total + resultsPerPage - 1) / resultsPerPage
+	}
+	return total, pages
+}
+
+// parseCount extracts the first number from text such as "1,234 results",
+// returning 0 when there is none.
+func parseCount(text string) int {
+	var digits strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ',' && digits.Len() > 0:
+		case digits.Len() > 0:
+			n, _ := strconv.Atoi(digits.String())
+			return n
+		}
+	}
+	n, _ := strconv.Atoi(digits.String())
+	return n
+}
+
+// bidsCountNum parses a card's bid count text, or returns nil when it shows
+// no count.
+func bidsCountNum(text string) *int {
+	if !strings.ContainsAny(text, "0123456789") {
+		return nil
+	}
+	n := parseCount(text)
+	return &n
+}
+
+// defaultLinkBase is what relative card links are resolved against unless
+// --link-base says otherwise.
+const defaultLinkBase = "https://www.freelancer.com"
+
+// resolveLink applies --link-base and --relative-links to a scraped href.
+// Relative hrefs are resolved against the link base; with --relative-links,
+// hrefs are kept as paths and absolute Freelancer URLs are cut down to their
+// path too, so every project link has the same form. Links to other hosts
+// are left alone.
+func resolveLink(href string) string {
+	if href == "" {
+		return ""
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	if relativeLinks {
+		if u.Host == "" || isFreelancerHost(u.Host) {
+			u.Scheme, u.Host, u.User = "", "", nil
+			return u.String()
+		}
+		return href
+	}
+	if u.IsAbs() {
+		return href
+	}
+	b := linkBase
+	if b == "" {
+		b = defaultLinkBase
+	}
+	base, err := url.Parse(b)
+	if err != nil || !base.IsAbs() {
+		return href
+	}
+	return base.ResolveReference(u).String()
+}
+
+// freelancerDomains are the registrable domains of freelancer.com and its
+// regional sites.
+var freelancerDomains = []string{
+	"freelancer.com", "freelancer.co.uk", "freelancer.ca", "freelancer.com.au",
+	"freelancer.co.nz", "freelancer.in", "freelancer.pk", "freelancer.com.bd",
+	"freelancer.ph", "freelancer.co.id", "freelancer.my", "freelancer.sg",
+	"freelancer.hk", "freelancer.jp", "freelancer.co.za", "freelancer.ie",
+	"freelancer.de", "freelancer.es", "freelancer.fr", "freelancer.it",
+	"freelancer.pt", "freelancer.se", "freelancer.pl", "freelancer.hu",
+	"freelancer.cz", "freelancer.ro", "freelancer.mx", "freelancer.cl",
+	"freelancer.com.ar", "freelancer.com.co", "freelancer.com.pe", "freelancer.com.jm",
+}
+
+// isFreelancerHost reports whether host, with or without a port, is one of
+// freelancerDomains or a subdomain of one, such as www.freelancer.co.uk.
+// Lookalikes such as freelancer.example.com don't match.
+func isFreelancerHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, d := range freelancerDomains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectActionSegments are trailing path segments of project links that
+// lead to a view of the project rather than naming it, such as the bid
+// button's /details.
+var projectActionSegments = map[string]bool{"details": true, "proposals": true, "ctas-btn": true, "bid": true, "place-bid": true}
+
+// canonicalLink returns the stable form of a project link, whichever anchor
+// it came from: an absolute https URL on the link's host (or the link base)
+// with the path up to the project's slug, and no query, fragment, action
+// segment or trailing slash. It returns "" for links it can't parse.
+func canonicalLink(link string) string {
+	if link == "" {
+		return ""
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	if !u.IsAbs() {
+		b := linkBase
+		if b == "" {
+			b = defaultLinkBase
+		}
+		base, err := url.Parse(b)
+		if err != nil || !base.IsAbs() {
+			return ""
+		}
+		u = base.ResolveReference(u)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for len(segments) > 2 && projectActionSegments[strings.ToLower(segments[len(segments)-1])] {
+		segments = segments[:len(segments)-1]
+	}
+	c := url.URL{Scheme: "https", Host: strings.ToLower(u.Host), Path: "/" + strings.Join(segments, "/")}
+	return c.String()
+}
+
+// projectKey identifies a project across pages and queries for dedup. The
+// canonical link is used, so the same project reached through different
+// anchors, or saved by an older version without canonical_link, matches.
+func projectKey(p Project) string {
+	if p.CanonicalLink != "" {
+		return p.CanonicalLink
+	}
+	if c := canonicalLink(p.Link); c != "" {
+		return c
+	}
+	return p.Link
+}
+
+// cleanText turns line breaks into spaces, collapses runs of spaces and
+// trims the result, in a single pass. Other whitespace, such as tabs, is
+// kept as it is, apart from being trimmed from the ends.
+func cleanText(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	space := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == ' ' || c == '\n' || c == '\r' {
+			space = true
+			continue
+		}
+		if space && sb.Len() > 0 {
+			sb.WriteByte(' ')
+		}
+		space = false
+		sb.WriteByte(c)
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,695 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// scrapeFixture runs Scrape on testdata/name, served over HTTP like a
+// search page.
+func scrapeFixture(t *testing.T, name string) *PageResult {
+	t.Helper()
+	return scrapeFixtureWith(t, name, Options{})
+}
+
+// scrapeFixtureWith is scrapeFixture with Scrape options other than the URL
+// and client.
+func scrapeFixtureWith(t *testing.T, name string, opts Options) *PageResult {
+	t.Helper()
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	t.Cleanup(srv.Close)
+	opts.URL, opts.Client = srv.URL+"/"+name, srv.Client()
+	result, err := Scrape(opts)
+	if err != nil {
+		t.Fatalf("scraping %s: %v", name, err)
+	}
+	return result
+}
+
+// fixtureProject returns the project titled title from result.
+func fixtureProject(t *testing.T, result *PageResult, title string) Project {
+	t.Helper()
+	for _, p := range result.Projects {
+		if p.Title == title {
+			return p
+		}
+	}
+	t.Fatalf("no project titled %q", title)
+	return Project{}
+}
+
+func TestScrapeSealedProjects(t *testing.T) {
+	result := scrapeFixture(t, "sealed.html")
+	tests := []struct {
+		title      string
+		sealed     bool
+		averageBid string
+		budget     string
+	}{
+		{"Sealed badge project", true, "", "$250 - $750 USD"},
+		{"Sealed without badge", true, "", "$30 - $250 USD"},
+		{"Open project", false, "$180 USD", ""},
+		{"New project", false, "", "$100 - $300 USD"},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.Sealed != tt.sealed {
+			t.Errorf("%s: Sealed = %v, want %v", tt.title, p.Sealed, tt.sealed)
+		}
+		if p.AverageBid != tt.averageBid {
+			t.Errorf("%s: AverageBid = %q, want %q", tt.title, p.AverageBid, tt.averageBid)
+		}
+		if p.Budget != tt.budget {
+			t.Errorf("%s: Budget = %q, want %q", tt.title, p.Budget, tt.budget)
+		}
+	}
+}
+
+// titles lists the titles of projects, in order.
+func titles(projects []Project) []string {
+	var out []string
+	for _, p := range projects {
+		out = append(out, p.Title)
+	}
+	return out
+}
+
+func TestScrapePriceType(t *testing.T) {
+	result := scrapeFixture(t, "price_type.html")
+	tests := []struct {
+		title     string
+		priceType string
+	}{
+		{"Hourly range", priceTypeHourly},
+		{"Hourly average", priceTypeHourly},
+		{"Fixed range", priceTypeFixed},
+		{"Fixed average", priceTypeFixed},
+		{"No price", priceTypeUnknown},
+	}
+	for _, tt := range tests {
+		if p := fixtureProject(t, result, tt.title); p.PriceType != tt.priceType {
+			t.Errorf("%s: PriceType = %q, want %q", tt.title, p.PriceType, tt.priceType)
+		}
+	}
+
+	filters := []struct {
+		flag string
+		want []string
+	}{
+		{"only-hourly", []string{"Hourly range", "Hourly average"}},
+		{"only-fixed", []string{"Fixed range", "Fixed average"}},
+	}
+	for _, f := range filters {
+		resetFlags(t)
+		setFlags(t, [][2]string{{f.flag, "true"}})
+		if got := titles(filterProjects(result.Projects)); !slices.Equal(got, f.want) {
+			t.Errorf("--%s kept %q, want %q", f.flag, got, f.want)
+		}
+	}
+}
+
+func TestScrapePostedAt(t *testing.T) {
+	start := time.Now()
+	result := scrapeFixture(t, "posted.html")
+	tests := []struct {
+		title string
+		ago   time.Duration // negative when there's no posting time
+	}{
+		{"Posted hours ago", 3 * time.Hour},
+		{"Posted just now", 0},
+		{"Posted in description", -1},
+		{"No posted time", -1},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if tt.ago < 0 {
+			if !p.PostedAt.IsZero() {
+				t.Errorf("%s: PostedAt = %v, want none", tt.title, p.PostedAt)
+			}
+			continue
+		}
+		if ago := start.Sub(p.PostedAt); ago < tt.ago-time.Minute || ago > tt.ago+time.Minute {
+			t.Errorf("%s: posted %v ago, want %v", tt.title, ago, tt.ago)
+		}
+	}
+
+	resetFlags(t)
+	setFlags(t, [][2]string{{"posted-within", "1h"}})
+	if got, want := titles(filterProjects(result.Projects)), []string{"Posted just now"}; !slices.Equal(got, want) {
+		t.Errorf("--posted-within 1h kept %q, want %q", got, want)
+	}
+}
+
+func TestScrapeSponsored(t *testing.T) {
+	result := scrapeFixture(t, "sponsored.html")
+	if got, want := titles(result.Projects), []string{"Real project", "Real contest"}; !slices.Equal(got, want) {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+	if result.Cards != 5 || result.Sponsored != 3 {
+		t.Errorf("Cards, Sponsored = %d, %d; want 5, 3", result.Cards, result.Sponsored)
+	}
+
+	result = scrapeFixtureWith(t, "sponsored.html", Options{IncludeSponsored: true})
+	if len(result.Projects) != 5 || result.Sponsored != 0 {
+		t.Errorf("with IncludeSponsored kept %d projects and counted %d sponsored; want 5, 0", len(result.Projects), result.Sponsored)
+	}
+}
+
+func TestScrapeEmployerReviews(t *testing.T) {
+	result := scrapeFixture(t, "employer_reviews.html")
+	tests := []struct {
+		title   string
+		rating  float64
+		reviews int
+	}{
+		{"Review count attribute", 4.8, 1204},
+		{"Review count text", 5, 3},
+		{"Rating without reviews", 5, 0},
+		{"No employer details", 0, 0},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.EmployerRating != tt.rating || p.EmployerReviews != tt.reviews {
+			t.Errorf("%s: rating, reviews = %v, %d; want %v, %d", tt.title, p.EmployerRating, p.EmployerReviews, tt.rating, tt.reviews)
+		}
+	}
+
+	resetFlags(t)
+	setFlags(t, [][2]string{{"min-reviews", "3"}, {"min-rating", "4.5"}})
+	if got, want := titles(filterProjects(result.Projects)), []string{"Review count attribute", "Review count text"}; !slices.Equal(got, want) {
+		t.Errorf("--min-reviews 3 --min-rating 4.5 kept %q, want %q", got, want)
+	}
+}
+
+func TestScrapeAnonymousListings(t *testing.T) {
+	result := scrapeFixture(t, "anonymous.html")
+	tests := []struct {
+		title       string
+		hasEmployer bool
+		country     string
+		jsonKeys    []string
+	}{
+		{"Anonymous listing", false, "", nil},
+		{"New employer", true, "", []string{"has_employer_info"}},
+		{"Country only", true, "gb", []string{"employer_country", "has_employer_info"}},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.HasEmployerInfo != tt.hasEmployer || p.EmployerCountry != tt.country || p.EmployerRating != 0 || p.EmployerReviews != 0 {
+			t.Errorf("%s: employer info %v, country %q, rating %v, reviews %d; want %v, %q, 0, 0",
+				tt.title, p.HasEmployerInfo, p.EmployerCountry, p.EmployerRating, p.EmployerReviews, tt.hasEmployer, tt.country)
+		}
+
+		raw, err := json.Marshal(outputProject(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]any
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			t.Fatal(err)
+		}
+		var keys []string
+		for k := range fields {
+			if k == "has_employer_info" || strings.HasPrefix(k, "employer_") {
+				keys = append(keys, k)
+			}
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, tt.jsonKeys) {
+			t.Errorf("%s: JSON has employer keys %q, want %q", tt.title, keys, tt.jsonKeys)
+		}
+	}
+}
+
+func TestScrapeAttachments(t *testing.T) {
+	result := scrapeFixture(t, "attachments.html")
+	tests := []struct {
+		title       string
+		attachments int
+	}{
+		{"Attachment count", 3},
+		{"Attachment icons", 2},
+		{"No attachments", 0},
+	}
+	for _, tt := range tests {
+		if p := fixtureProject(t, result, tt.title); p.Attachments != tt.attachments {
+			t.Errorf("%s: Attachments = %d, want %d", tt.title, p.Attachments, tt.attachments)
+		}
+	}
+
+	// With --full-description the project page's list is counted too.
+	resetDetailCache()
+	srv := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+	p := fixtureProject(t, result, "No attachments")
+	p.Link = srv.URL + "/project_page.html"
+	projects := []Project{p}
+	fetchDetails(context.Background(), srv.Client(), projects)
+	if projects[0].Attachments != 2 {
+		t.Errorf("after fetching details, Attachments = %d, want 2", projects[0].Attachments)
+	}
+	if !strings.HasSuffix(projects[0].Description, "lists the spec and a mockup.") || projects[0].Summary != p.Description {
+		t.Errorf("after fetching details, Description = %q and Summary = %q", projects[0].Description, projects[0].Summary)
+	}
+}
+
+func TestFetchDetailsWithRelativeLinks(t *testing.T) {
+	resetFlags(t)
+	resetDetailCache()
+	setFlags(t, [][2]string{{"relative-links", "true"}})
+	result := scrapeFixture(t, "attachments.html")
+	p := fixtureProject(t, result, "No attachments")
+	if strings.Contains(p.Link, "://") {
+		t.Fatalf("Link = %q, want a path with --relative-links", p.Link)
+	}
+
+	// Every project page is served by the test server, whatever host the
+	// request names.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/project_page.html")
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+	projects := []Project{p}
+	fetchDetails(context.Background(), &http.Client{Transport: rewriteTransport{target: target}}, projects)
+	if projects[0].Attachments != 2 || !strings.HasSuffix(projects[0].Description, "lists the spec and a mockup.") {
+		t.Errorf("after fetching details, Attachments = %d and Description = %q", projects[0].Attachments, projects[0].Description)
+	}
+	if projects[0].Link != p.Link {
+		t.Errorf("Link changed to %q, want the relative %q", projects[0].Link, p.Link)
+	}
+}
+
+func TestFetchDetailsStopsWhenInterrupted(t *testing.T) {
+	resetDetailCache()
+	defer func(old int) { queryWorkers = old }(queryWorkers)
+	queryWorkers = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The first page request is interrupted while it's in flight, and hangs
+	// until it's abandoned.
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	projects := []Project{
+		{Title: "One", Link: srv.URL + "/one", Description: "Card one"},
+		{Title: "Two", Link: srv.URL + "/two", Description: "Card two"},
+		{Title: "Three", Link: srv.URL + "/three", Description: "Card three"},
+	}
+	start := time.Now()
+	fetchDetails(ctx, srv.Client(), projects)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("fetchDetails took %v after the interrupt", elapsed)
+	}
+	if requests != 1 {
+		t.Errorf("%d pages requested, want only the one in flight when interrupted", requests)
+	}
+	for _, p := range projects {
+		if p.Description != "Card "+strings.ToLower(p.Title) || p.Summary != "" {
+			t.Errorf("%s: Description, Summary = %q, %q; want the card's", p.Title, p.Description, p.Summary)
+		}
+	}
+}
+
+func TestReadDetailPageStopsAtSelectedFields(t *testing.T) {
+	description := `<html><body><div class="ProjectDescription">Full text, <b>bold</b> <div>nested</div></div>`
+	emptyList := `<ul class="AttachmentsList"></ul>`
+	list := `<ul class="AttachmentsList"><li class="AttachmentsList-item"><a href="/projects/1/attachments/a.pdf">a.pdf</a></li></ul>`
+	tests := []struct {
+		name   string
+		fields string
+		page   string
+		want   string // what's read before stopping; "" when the whole page is needed
+	}{
+		{"description", "description", description + list, description},
+		{"attachments", "attachments", description + emptyList + list, description + emptyList + list},
+		{"both", "description,attachments", list + description, list + description},
+		{"all by default", "", description + list, description + list},
+		{"missing list", "attachments", description + emptyList, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(t)
+			if tt.fields != "" {
+				setFlags(t, [][2]string{{"detail-fields", tt.fields}})
+			}
+			// Reading past the page given fails, as a stand-in for the
+			// rest of a large page.
+			r := io.MultiReader(strings.NewReader(tt.page), iotest.ErrReader(errors.New("read past the selected fields")))
+			got, err := readDetailPage(r)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("stopped after %q, want the whole page read", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("read %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// resetDetailCache forgets the project pages fetched by earlier tests.
+func resetDetailCache() {
+	detailCache.Lock()
+	detailCache.entries = make(map[string]*detailEntry)
+	detailCache.Unlock()
+}
+
+func TestFetchDetailsFetchesEachPageOnce(t *testing.T) {
+	resetDetailCache()
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		http.ServeFile(w, r, "testdata/project_page.html")
+	}))
+	defer srv.Close()
+
+	// The same project through its heading and its bid button, and again
+	// in a later call.
+	projects := []Project{
+		{Title: "Heading", Link: srv.URL + "/projects/php/same"},
+		{Title: "Button", Link: srv.URL + "/projects/php/same/details?ref=search"},
+	}
+	fetchDetails(context.Background(), srv.Client(), projects)
+	again := []Project{{Title: "Later", Link: srv.URL + "/projects/php/same/"}}
+	fetchDetails(context.Background(), srv.Client(), again)
+	if requests != 1 {
+		t.Errorf("the page was requested %d times, want once", requests)
+	}
+	for _, p := range append(projects, again...) {
+		if p.Attachments != 2 {
+			t.Errorf("%s: Attachments = %d, want the page's 2", p.Title, p.Attachments)
+		}
+	}
+}
+
+func TestScrapeAverageBidOnly(t *testing.T) {
+	result := scrapeFixture(t, "avg_bid.html")
+	tests := []struct {
+		title      string
+		averageBid string
+		amount     float64
+		budget     string
+		budgetMin  float64
+		budgetMax  float64
+		currency   string
+	}{
+		{"Labelled average", "$180 USD", 180, "", 0, 0, "USD"},
+		{"Localized average", "€95 EUR", 95, "", 0, 0, "EUR"},
+		{"Unlabelled average", "$1,250 USD", 1250, "", 0, 0, "USD"},
+		{"Budget range", "", 0, "$250 - $750 USD", 250, 750, "USD"},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.AverageBid != tt.averageBid || p.AverageBidAmount != tt.amount {
+			t.Errorf("%s: average bid %q (%v), want %q (%v)", tt.title, p.AverageBid, p.AverageBidAmount, tt.averageBid, tt.amount)
+		}
+		if p.Budget != tt.budget || p.BudgetMin != tt.budgetMin || p.BudgetMax != tt.budgetMax {
+			t.Errorf("%s: budget %q (%v-%v), want %q (%v-%v)", tt.title, p.Budget, p.BudgetMin, p.BudgetMax, tt.budget, tt.budgetMin, tt.budgetMax)
+		}
+		if p.Currency != tt.currency || p.Sealed {
+			t.Errorf("%s: currency %q, sealed %v; want %q, false", tt.title, p.Currency, p.Sealed, tt.currency)
+		}
+	}
+}
+
+func TestScrapeContestEntries(t *testing.T) {
+	result := scrapeFixture(t, "contests.html")
+	tests := []struct {
+		title   string
+		entries int
+		bids    string
+	}{
+		{"Logo contest", 12, ""},
+		{"Banner contest", 1, ""},
+		{"Mascot contest", 5, ""},
+		{"Logo project", 0, "14 bids"},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.Entries != tt.entries || p.BidsCount != tt.bids {
+			t.Errorf("%s: Entries, BidsCount = %d, %q; want %d, %q", tt.title, p.Entries, p.BidsCount, tt.entries, tt.bids)
+		}
+		if (p.BidsCountNum != nil) != (tt.bids != "") {
+			t.Errorf("%s: BidsCountNum = %v, want it set only for bids", tt.title, p.BidsCountNum)
+		}
+		if p.Sealed {
+			t.Errorf("%s: Sealed, want entries not to count as hidden bids", tt.title)
+		}
+	}
+}
+
+func TestScrapeClosedProjects(t *testing.T) {
+	result := scrapeFixture(t, "closed.html")
+	tests := []struct {
+		title  string
+		closed bool
+	}{
+		{"Open listing", false},
+		{"Expired listing", true},
+		{"No time left", true},
+		{"Closed badge", true},
+		{"Ten days left", false},
+	}
+	for _, tt := range tests {
+		if p := fixtureProject(t, result, tt.title); p.Closed != tt.closed {
+			t.Errorf("%s: Closed = %v, want %v", tt.title, p.Closed, tt.closed)
+		}
+	}
+
+	resetFlags(t)
+	if got, want := titles(filterProjects(result.Projects)), []string{"Open listing", "Ten days left"}; !slices.Equal(got, want) {
+		t.Errorf("by default kept %q, want %q", got, want)
+	}
+	setFlags(t, [][2]string{{"include-closed", "true"}})
+	if got := filterProjects(result.Projects); len(got) != len(result.Projects) {
+		t.Errorf("--include-closed kept %q, want every project", titles(got))
+	}
+}
+
+func TestScrapeLocation(t *testing.T) {
+	result := scrapeFixture(t, "location.html")
+	tests := []struct {
+		title    string
+		location string
+		remote   bool
+	}{
+		{"London photographer", "London, United Kingdom", false},
+		{"Berlin movers", "Berlin, Germany", false},
+		{"Remote badge", "", true},
+		{"Remote location line", "", true},
+		{"No location", "", false},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.Location != tt.location || p.Remote != tt.remote {
+			t.Errorf("%s: Location, Remote = %q, %v; want %q, %v", tt.title, p.Location, p.Remote, tt.location, tt.remote)
+		}
+	}
+
+	filters := []struct {
+		flag, value string
+		want        []string
+	}{
+		{"only-remote", "true", []string{"Remote badge", "Remote location line"}},
+		{"location", "london", []string{"London photographer"}},
+		{"location", "united kingdom", []string{"London photographer"}},
+	}
+	for _, f := range filters {
+		resetFlags(t)
+		setFlags(t, [][2]string{{f.flag, f.value}})
+		if got := titles(filterProjects(result.Projects)); !slices.Equal(got, f.want) {
+			t.Errorf("--%s %s kept %q, want %q", f.flag, f.value, got, f.want)
+		}
+	}
+}
+
+// cleanTextOld is cleanText as it was before the single-pass rewrite, kept
+// to check the two agree.
+func cleanTextOld(s string) string {
+	s = strings.ReplaceAll(s, "\n", " ")
+	s = strings.ReplaceAll(s, "\r", " ")
+	for strings.Contains(s, "  ") {
+		s = strings.ReplaceAll(s, "  ", " ")
+	}
+	return strings.TrimSpace(s)
+}
+
+var cleanTextCases = []string{
+	"",
+	"   ",
+	"plain",
+	"  leading and trailing  ",
+	"line\nbreaks\r\nand\rreturns",
+	"many     spaces   between",
+	"\n\n  blank lines  \n\n",
+	"tab\tkept",
+	"space \t around tab",
+	"\t leading tab",
+	"trailing tab \t",
+	" non-breaking  space ",
+	"unicode café  naïve\n日本語  テキスト",
+	"$250 - $750 USD\n      \n      14 bids",
+}
+
+func TestCanonicalLink(t *testing.T) {
+	tests := []struct {
+		link, want string
+	}{
+		{"/projects/php/one", "https://www.freelancer.com/projects/php/one"},
+		{"/projects/php/one/", "https://www.freelancer.com/projects/php/one"},
+		{"/projects/php/one/details?ref=search", "https://www.freelancer.com/projects/php/one"},
+		{"/projects/php/one/ctas-btn", "https://www.freelancer.com/projects/php/one"},
+		{"/projects/php/one/place-bid#bid", "https://www.freelancer.com/projects/php/one"},
+		{"http://WWW.Freelancer.co.uk/projects/php/one/proposals", "https://www.freelancer.co.uk/projects/php/one"},
+		{"/projects/details", "https://www.freelancer.com/projects/details"},
+		{"/contest/logo-design-123", "https://www.freelancer.com/contest/logo-design-123"},
+		{"", ""},
+		{"%zz", ""},
+	}
+	resetFlags(t)
+	for _, tt := range tests {
+		if got := canonicalLink(tt.link); got != tt.want {
+			t.Errorf("canonicalLink(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestScrapeLinkShapes(t *testing.T) {
+	result := scrapeFixture(t, "links.html")
+	tests := []struct {
+		title, link, canonical string
+	}{
+		{"Heading only", "https://www.freelancer.com/projects/php/heading-only", "https://www.freelancer.com/projects/php/heading-only"},
+		{"Bid button", "https://www.freelancer.com/projects/php/bid-button/details?ref=search", "https://www.freelancer.com/projects/php/bid-button"},
+		{"Absolute button", "https://www.freelancer.com/projects/php/absolute-button/place-bid#bid", "https://www.freelancer.com/projects/php/absolute-button"},
+	}
+	for _, tt := range tests {
+		p := fixtureProject(t, result, tt.title)
+		if p.Link != tt.link {
+			t.Errorf("%s: Link = %q, want the scraped %q", tt.title, p.Link, tt.link)
+		}
+		if p.CanonicalLink != tt.canonical {
+			t.Errorf("%s: CanonicalLink = %q, want %q", tt.title, p.CanonicalLink, tt.canonical)
+		}
+		// The heading anchor names the same project.
+		heading := strings.TrimSuffix(strings.TrimPrefix(tt.canonical, "https://www.freelancer.com"), "/") + "/"
+		if got := canonicalLink(heading); got != p.CanonicalLink {
+			t.Errorf("%s: heading anchor %s canonicalizes to %q, the card to %q", tt.title, heading, got, p.CanonicalLink)
+		}
+	}
+}
+
+func TestIsFreelancerHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"freelancer.com", true},
+		{"www.freelancer.com", true},
+		{"WWW.Freelancer.COM", true},
+		{"www.freelancer.com:443", true},
+		{"www.freelancer.com.", true},
+		{"www.freelancer.co.uk", true},
+		{"www.freelancer.com.au", true},
+		{"freelancer.in", true},
+		{"freelancer.evil.com", false},
+		{"x.freelancer.attacker.net", false},
+		{"notfreelancer.com", false},
+		{"freelancer.com.evil.net", false},
+		{"freelancer.co", false},
+		{"example.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isFreelancerHost(tt.host); got != tt.want {
+			t.Errorf("isFreelancerHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags [][2]string
+		href  string
+		want  string
+	}{
+		{"relative", nil, "/projects/php/one", "https://www.freelancer.com/projects/php/one"},
+		{"absolute", nil, "https://www.freelancer.co.uk/projects/php/one", "https://www.freelancer.co.uk/projects/php/one"},
+		{"link base", [][2]string{{"link-base", "https://www.freelancer.co.uk"}}, "/projects/php/one", "https://www.freelancer.co.uk/projects/php/one"},
+		{"relative links keep paths", [][2]string{{"relative-links", "true"}}, "/projects/php/one?ref=x", "/projects/php/one?ref=x"},
+		{"relative links cut freelancer URLs", [][2]string{{"relative-links", "true"}}, "https://www.freelancer.co.uk/projects/php/one", "/projects/php/one"},
+		{"relative links keep other hosts", [][2]string{{"relative-links", "true"}}, "https://freelancer.evil.com/projects/php/one", "https://freelancer.evil.com/projects/php/one"},
+		{"empty", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(t)
+			setFlags(t, tt.flags)
+			if got := resolveLink(tt.href); got != tt.want {
+				t.Errorf("resolveLink(%q) = %q, want %q", tt.href, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanTextMatchesOld(t *testing.T) {
+	for _, s := range cleanTextCases {
+		if got, want := cleanText(s), cleanTextOld(s); got != want {
+			t.Errorf("cleanText(%q) = %q, want %q", s, got, want)
+		}
+	}
+}
+
+func FuzzCleanText(f *testing.F) {
+	for _, s := range cleanTextCases {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		if got, want := cleanText(s), cleanTextOld(s); got != want {
+			t.Errorf("cleanText(%q) = %q, want %q", s, got, want)
+		}
+	})
+}
+
+func BenchmarkCleanText(b *testing.B) {
+	s := strings.Repeat("A description   with\n\n   scattered    whitespace,\r\n  as card text has.  ", 40)
+	b.Run("old", func(b *testing.B) {
+		for b.Loop() {
+			cleanTextOld(s)
+		}
+	})
+	b.Run("new", func(b *testing.B) {
+		for b.Loop() {
+			cleanText(s)
+		}
+	})
+}
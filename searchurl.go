@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// searchURLFlags maps the search page's query parameters to the flags
+// buildURL sets them from.
+var searchURLFlags = []struct{ param, flag string }{
+	{"q", "q"},
+	{"types", "types"},
+	{"clientCountries", "clientCountries"},
+	{"projectFixedPriceMin", "fixedMin"},
+	{"projectFixedPriceMax", "fixedMax"},
+	{"projectHourlyRateMin", "hourlyMin"},
+	{"projectHourlyRateMax", "hourlyMax"},
+	{"projectSkills", "skills"},
+	{"projectSort", "sort"},
+	{"page", "page"},
+}
+
+// searchURLAbsent holds the values of the flags whose parameter buildURL
+// leaves out for something other than the flag's default; that value is what
+// a URL without the parameter means.
+var searchURLAbsent = map[string]string{
+	"types":           "",
+	"clientCountries": "",
+	"skills":          "all",
+}
+
+// applySearchURL sets the search flags from a search page URL copied from
+// Freelancer, for --url: the inverse of buildURL. Flags given explicitly
+// win over the URL's parameters. Parameters with no flag are passed on as
+// --param pairs.
+func applySearchURL(rawURL string) error {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(u.Hostname())
+	if (u.Scheme != "https" && u.Scheme != "http") || (host != "freelancer.com" && !strings.HasSuffix(host, ".freelancer.com")) {
+		return fmt.Errorf("%q is not a freelancer.com URL", rawURL)
+	}
+	if strings.TrimSuffix(u.Path, "/") != "/search/projects" {
+		return fmt.Errorf("%q is not a project search URL (expected a /search/projects path)", rawURL)
+	}
+
+	q := u.Query()
+	var ignored []string
+	set := func(flag, value string) error {
+		if commandFlags.Changed(flag) {
+			ignored = append(ignored, "--"+flag)
+			return nil
+		}
+		if err := commandFlags.Set(flag, value); err != nil {
+			return fmt.Errorf("--url parameter for --%s: %w", flag, err)
+		}
+		return nil
+	}
+	for _, m := range searchURLFlags {
+		value, ok := searchURLAbsent[m.flag]
+		if q.Has(m.param) {
+			value, ok = q.Get(m.param), true
+		}
+		if !ok {
+			continue
+		}
+		if err := set(m.flag, value); err != nil {
+			return err
+		}
+		q.Del(m.param)
+	}
+	if q.Has("projectUpgrades") {
+		upgrades := strings.Split(q.Get("projectUpgrades"), ",")
+		for _, upgrade := range upgrades {
+			if !slices.Contains(projectUpgrades, upgrade) {
+				return fmt.Errorf("--url has unknown project upgrade %q", upgrade)
+			}
+			if err := set("only-"+upgrade, "true"); err != nil {
+				return err
+			}
+		}
+		q.Del("projectUpgrades")
+	}
+	for _, key := range slices.Sorted(maps.Keys(q)) {
+		for _, value := range q[key] {
+			if err := commandFlags.Set("param", key+"="+value); err != nil {
+				return err
+			}
+		}
+	}
+	if len(ignored) > 0 {
+		log.Printf("Warning: %s given explicitly, so --url's value is ignored", strings.Join(ignored, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"maps"
+	"net/url"
+	"testing"
+)
+
+// resetFlags gives the root command a fresh flag set, putting every flag
+// variable back to its default, now and again when t ends.
+func resetFlags(t *testing.T) {
+	t.Helper()
+	rootCmd.ResetFlags()
+	registerFlags()
+	t.Cleanup(func() {
+		rootCmd.ResetFlags()
+		registerFlags()
+	})
+}
+
+// setFlags sets flags as if they were given on the command line.
+func setFlags(t *testing.T, flags [][2]string) {
+	t.Helper()
+	for _, f := range flags {
+		if err := commandFlags.Set(f[0], f[1]); err != nil {
+			t.Fatalf("--%s=%s: %v", f[0], f[1], err)
+		}
+	}
+}
+
+func TestSearchURLRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags [][2]string
+	}{
+		{"defaults", nil},
+		{"query and page", [][2]string{{"q", "golang api"}, {"page", "3"}}},
+		{"fixed price range", [][2]string{{"types", "fixed"}, {"fixedMin", "100"}, {"fixedMax", "500"}}},
+		{"hourly range", [][2]string{{"types", "hourly"}, {"hourlyMin", "15"}, {"hourlyMax", "40"}}},
+		{"no type", [][2]string{{"types", ""}}},
+		{"skills", [][2]string{{"skills", "3,13,17"}}},
+		{"all skills", [][2]string{{"skills", "all"}}},
+		{"countries", [][2]string{{"clientCountries", "us,gb,de"}}},
+		{"no countries", [][2]string{{"clientCountries", ""}}},
+		{"sort", [][2]string{{"sort", "lowestPrice"}}},
+		{"upgrades", [][2]string{{"only-sealed", "true"}, {"only-nda", "true"}}},
+		{"extra params", [][2]string{{"param", "languages=en"}, {"param", "languages=fr"}, {"param", "projectLanguages=en"}}},
+		{"unicode query", [][2]string{{"q", "café & bar/è"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(t)
+			setFlags(t, tt.flags)
+			wantURL, wantParams := buildURL(queryText, pageNumber)
+
+			resetFlags(t)
+			if err := applySearchURL(wantURL); err != nil {
+				t.Fatalf("applySearchURL(%s): %v", wantURL, err)
+			}
+			gotURL, gotParams := buildURL(queryText, pageNumber)
+			if gotURL != wantURL {
+				t.Errorf("round trip changed the URL\ngot:  %s\nwant: %s", gotURL, wantURL)
+			}
+			if !maps.Equal(gotParams, wantParams) {
+				t.Errorf("round trip changed the parameters\ngot:  %v\nwant: %v", gotParams, wantParams)
+			}
+		})
+	}
+}
+
+func TestApplySearchURLExplicitFlagsWin(t *testing.T) {
+	resetFlags(t)
+	setFlags(t, [][2]string{{"q", "explicit"}})
+	if err := applySearchURL("https://www.freelancer.com/search/projects?q=from-url&projectSort=oldest"); err != nil {
+		t.Fatal(err)
+	}
+	if queryText != "explicit" {
+		t.Errorf("queryText = %q, want the explicit flag's value", queryText)
+	}
+	if sortOption != "oldest" {
+		t.Errorf("sortOption = %q, want the URL's oldest", sortOption)
+	}
+}
+
+func TestApplySearchURLRejects(t *testing.T) {
+	for _, raw := range []string{
+		"https://evil.com/search/projects?q=go",
+		"https://freelancer.com.evil.com/search/projects",
+		"https://evilfreelancer.com/search/projects",
+		"ftp://www.freelancer.com/search/projects",
+		"https://www.freelancer.com/projects/php/some-project",
+		"https://www.freelancer.com/search/projects?projectUpgrades=bogus",
+		"https://www.freelancer.com/search/projects?page=two",
+	} {
+		resetFlags(t)
+		if err := applySearchURL(raw); err == nil {
+			t.Errorf("applySearchURL(%s) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestBuildURLPriceZeroVersusUnset(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   [][2]string
+		want    map[string]string
+		wantAPI map[string]string
+	}{
+		{
+			name: "unset",
+		},
+		{
+			name:    "fixed min given as 0",
+			flags:   [][2]string{{"fixedMin", "0"}},
+			want:    map[string]string{"projectFixedPriceMin": "0"},
+			wantAPI: map[string]string{"min_price": "0"},
+		},
+		{
+			name:    "zero minimums with maximums",
+			flags:   [][2]string{{"fixedMin", "0"}, {"fixedMax", "500"}, {"hourlyMin", "0"}, {"hourlyMax", "40"}},
+			want:    map[string]string{"projectFixedPriceMin": "0", "projectFixedPriceMax": "500", "projectHourlyRateMin": "0", "projectHourlyRateMax": "40"},
+			wantAPI: map[string]string{"min_price": "0", "max_price": "500", "min_hourly_rate": "0", "max_hourly_rate": "40"},
+		},
+		{
+			name:    "hourly max given as 0",
+			flags:   [][2]string{{"hourlyMax", "0"}},
+			want:    map[string]string{"projectHourlyRateMax": "0"},
+			wantAPI: map[string]string{"max_hourly_rate": "0"},
+		},
+	}
+	priceParams := []string{"projectFixedPriceMin", "projectFixedPriceMax", "projectHourlyRateMin", "projectHourlyRateMax"}
+	apiPriceParams := []string{"min_price", "max_price", "min_hourly_rate", "max_hourly_rate"}
+	// check compares the price parameters in link's query string with want;
+	// those missing from want must be absent, not merely empty.
+	check := func(t *testing.T, link string, params []string, want map[string]string) {
+		t.Helper()
+		u, err := url.Parse(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := u.Query()
+		for _, name := range params {
+			v, ok := want[name]
+			if got, has := q[name]; has != ok || (ok && got[0] != v) {
+				t.Errorf("%s: got %q (present %v), want %q (present %v)", name, got, has, v, ok)
+			}
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFlags(t)
+			setFlags(t, tt.flags)
+			link, params := buildURL("", 1)
+			check(t, link, priceParams, tt.want)
+			for _, name := range priceParams {
+				if _, ok := params[name]; ok != (tt.want[name] != "") {
+					t.Errorf("recorded parameters %v: %s present = %v", params, name, ok)
+				}
+			}
+			check(t, buildAPIURL("", 1), apiPriceParams, tt.wantAPI)
+		})
+	}
+}
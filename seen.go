@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultSeenFile is where --only-new keeps the projects already output,
+// inside --output-dir.
+const defaultSeenFile = ".flparser_seen"
+
+// defaultSeenKey is the Redis set --dedup-store uses unless the URL names
+// another with ?key=.
+const defaultSeenKey = "flparser:seen"
+
+// seenStore is the set of project keys (see projectKey) output by earlier
+// --only-new runs.
+type seenStore interface {
+	// filterNew returns the projects not in the store, preserving order.
+	filterNew(projects []Project) ([]Project, error)
+	// record adds the projects that were output to the store.
+	record(projects []Project) error
+}
+
+// seenProjects is the --only-new store of the current run, nil without
+// --only-new or once its projects are recorded.
+var seenProjects seenStore
+
+// recordSeen records the projects that were output in seenProjects.
+func recordSeen(projects []Project) error {
+	if seenProjects == nil {
+		return nil
+	}
+	err := seenProjects.record(projects)
+	seenProjects = nil
+	return err
+}
+
+// releaseSeen records nothing in seenProjects, for runs that fail before
+// their output is written: a shared store gives back the projects this run
+// claimed, so they aren't lost to every machine.
+func releaseSeen() {
+	if err := recordSeen(nil); err != nil {
+		log.Printf("Warning: could not release seen projects: %v", err)
+	}
+}
+
+// fileSeenStore is the default seenStore: keys one per line in a plain text
+// file.
+type fileSeenStore struct {
+	path string
+	keys map[string]bool
+}
+
+// seenPath returns the seen-store path for the current flags.
+func seenPath() string {
+	if seenFile != "" {
+		return seenFile
+	}
+	return filepath.Join(outputDir, defaultSeenFile)
+}
+
+// openSeenStore opens the store for the current flags: the Redis set named
+// by --dedup-store, or else the file at seenPath.
+func openSeenStore() (seenStore, error) {
+	if dedupStore != "" {
+		return openRedisSeenStore(dedupStore)
+	}
+	return openFileSeenStore(seenPath())
+}
+
+// resetSeenStore empties the store for the current flags and returns a
+// description of where it was.
+func resetSeenStore() (string, error) {
+	if dedupStore != "" {
+		s, err := openRedisSeenStore(dedupStore)
+		if err != nil {
+			return "", err
+		}
+		defer s.conn.close()
+		_, err = s.conn.command("DEL", s.key)
+		return fmt.Sprintf("Redis set %s", s.key), err
+	}
+	if err := os.Remove(seenPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	return seenPath(), nil
+}
+
+// openFileSeenStore loads the store at path; a missing file is an empty
+// store.
+func openFileSeenStore(path string) (*fileSeenStore, error) {
+	s := &fileSeenStore{path: path, keys: make(map[string]bool)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key := strings.TrimSpace(scanner.Text()); key != "" {
+			s.keys[key] = true
+		}
+	}
+	return s, scanner.Err()
+}
+
+func (s *fileSeenStore) filterNew(projects []Project) ([]Project, error) {
+	var fresh []Project
+	for _, p := range projects {
+		if !s.keys[projectKey(p)] {
+			fresh = append(fresh, p)
+		}
+	}
+	return fresh, nil
+}
+
+// record appends the keys of projects to the store file.
+func (s *fileSeenStore) record(projects []Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+	if dir := filepath.Dir(s.path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, p := range projects {
+		key := projectKey(p)
+		if key == "" || s.keys[key] {
+			continue
+		}
+		s.keys[key] = true
+		w.WriteString(key + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// redisSeenStore keeps the keys in a Redis set shared by several scrapers.
+// filterNew claims each project with SADD, which only one client can win, so
+// two machines never both output the same project as new. Claimed projects
+// that end up not being output (cut by --limit, say) are released by record
+// so a later run can still pick them up.
+type redisSeenStore struct {
+	conn    *redisBroker
+	key     string
+	claimed map[string]bool
+}
+
+// openRedisSeenStore connects to the --dedup-store URL. The set is named by
+// the ?key= parameter, and a numeric path selects the database.
+func openRedisSeenStore(rawURL string) (*redisSeenStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported --dedup-store scheme %q (expected redis://)", u.Scheme)
+	}
+	conn, err := dialRedis(u)
+	if err != nil {
+		return nil, err
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if _, err := strconv.Atoi(db); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("--dedup-store database %q is not a number", db)
+		}
+		if _, err := conn.command("SELECT", db); err != nil {
+			conn.close()
+			return nil, fmt.Errorf("redis SELECT: %w", err)
+		}
+	}
+	key := u.Query().Get("key")
+	if key == "" {
+		key = defaultSeenKey
+	}
+	return &redisSeenStore{conn: conn, key: key, claimed: make(map[string]bool)}, nil
+}
+
+func (s *redisSeenStore) filterNew(projects []Project) ([]Project, error) {
+	var fresh []Project
+	for _, p := range projects {
+		key := projectKey(p)
+		if key == "" {
+			fresh = append(fresh, p)
+			continue
+		}
+		if s.claimed[key] {
+			continue
+		}
+		reply, err := s.conn.command("SADD", s.key, key)
+		if err != nil {
+			return nil, fmt.Errorf("redis SADD: %w", err)
+		}
+		if reply == ":1" {
+			s.claimed[key] = true
+			fresh = append(fresh, p)
+		}
+	}
+	return fresh, nil
+}
+
+// record releases the claimed projects missing from projects, then closes
+// the connection.
+func (s *redisSeenStore) record(projects []Project) error {
+	defer s.conn.close()
+	output := make(map[string]bool, len(projects))
+	for _, p := range projects {
+		output[projectKey(p)] = true
+	}
+	args := []string{"SREM", s.key}
+	for key := range s.claimed {
+		if !output[key] {
+			args = append(args, key)
+		}
+	}
+	if len(args) == 2 {
+		return nil
+	}
+	_, err := s.conn.command(args...)
+	return err
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// redisSetServer is an in-process Redis that only knows SADD and SREM, on
+// one set, for testing the --dedup-store claims.
+type redisSetServer struct {
+	mu      sync.Mutex
+	members map[string]bool
+}
+
+// startRedisSetServer starts a redisSetServer and returns it with its
+// redis:// URL.
+func startRedisSetServer(t *testing.T) (*redisSetServer, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	srv := &redisSetServer{members: make(map[string]bool)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv, "redis://" + ln.Addr().String()
+}
+
+func (srv *redisSetServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPArray(r)
+		if err != nil {
+			return
+		}
+		srv.mu.Lock()
+		n := 0
+		for _, member := range args[2:] {
+			switch strings.ToUpper(args[0]) {
+			case "SADD":
+				if !srv.members[member] {
+					srv.members[member] = true
+					n++
+				}
+			case "SREM":
+				if srv.members[member] {
+					delete(srv.members, member)
+					n++
+				}
+			}
+		}
+		srv.mu.Unlock()
+		fmt.Fprintf(conn, ":%d\r\n", n)
+	}
+}
+
+// readRESPArray reads one command sent as a RESP array of bulk strings.
+func readRESPArray(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimRight(arg, "\r\n")
+	}
+	return args, nil
+}
+
+func (srv *redisSetServer) keys() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	var keys []string
+	for k := range srv.members {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestReleaseSeenGivesBackClaims(t *testing.T) {
+	srv, url := startRedisSetServer(t)
+	projects := []Project{
+		{Title: "One", Link: "https://www.freelancer.com/projects/php/one"},
+		{Title: "Two", Link: "https://www.freelancer.com/projects/php/two"},
+	}
+
+	store, err := openRedisSeenStore(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seenProjects = store
+	t.Cleanup(func() { seenProjects = nil })
+	fresh, err := seenProjects.filterNew(projects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 2 || len(srv.keys()) != 2 {
+		t.Fatalf("filterNew returned %d projects and claimed %v, want both", len(fresh), srv.keys())
+	}
+
+	releaseSeen()
+	if keys := srv.keys(); len(keys) != 0 {
+		t.Errorf("claims left after releaseSeen: %v", keys)
+	}
+	if seenProjects != nil {
+		t.Error("seenProjects still set after releaseSeen")
+	}
+
+	// A later run gets them again, and keeps what it output.
+	if seenProjects, err = openRedisSeenStore(url); err != nil {
+		t.Fatal(err)
+	}
+	if fresh, _ := seenProjects.filterNew(projects); len(fresh) != 2 {
+		t.Fatalf("second run got %d new projects, want 2", len(fresh))
+	}
+	if err := recordSeen(projects[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if keys, want := srv.keys(), []string{projectKey(projects[0])}; !slices.Equal(keys, want) {
+		t.Errorf("after recordSeen the set holds %v, want %v", keys, want)
+	}
+}
+
+func TestHandleOutputReportsWriteFailure(t *testing.T) {
+	resetFlags(t)
+	dir := t.TempDir()
+	blocker := filepath.Join(dir, "file")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	data := OutputData{Projects: []Project{{Title: "One"}}}
+
+	setFlags(t, [][2]string{{"output-dir", dir}, {"output", "out.json"}})
+	if !handleOutput(data) {
+		t.Error("handleOutput reported a failure writing into a directory")
+	}
+	setFlags(t, [][2]string{{"output-dir", filepath.Join(blocker, "sub")}})
+	if handleOutput(data) {
+		t.Error("handleOutput reported success with an output dir under a file")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveAddr       string
+	serveConcurrent int
+	serveCacheTTL   time.Duration
+	serveTimeout    time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve scrapes over an HTTP JSON API",
+	Long: `Start an HTTP server answering GET /projects with the same JSON that
+-X json writes. The query string selects the search:
+
+  q                search text
+  page             results page (default 1)
+  skills           skill IDs, comma separated, or "all"
+  types            hourly, fixed or hourly,fixed
+  clientCountries  client country codes, comma separated
+  sort             Freelancer's sort order, as for --sort
+
+Anything not given falls back to the defaults of the scraping flags.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		srv := &http.Server{
+			Addr:              serveAddr,
+			Handler:           newProjectServer(),
+			ReadHeaderTimeout: 10 * time.Second,
+			WriteTimeout:      serveTimeout + 10*time.Second,
+		}
+		fmt.Printf("Serving on %s (GET /projects)\n", serveAddr)
+		log.Fatal(srv.ListenAndServe())
+	},
+}
+
+// serveParams maps the /projects query parameters onto the search URL
+// parameters buildURL sets.
+var serveParams = map[string]string{
+	"skills":          "projectSkills",
+	"types":           "types",
+	"clientCountries": "clientCountries",
+	"sort":            "projectSort",
+}
+
+// projectServer answers /projects by scraping, with at most serveConcurrent
+// scrapes in flight and results cached by search URL.
+type projectServer struct {
+	client *http.Client
+	sem    chan struct{}
+	mu     sync.Mutex
+	cache  map[string]cachedSearch
+}
+
+type cachedSearch struct {
+	data    OutputData
+	expires time.Time
+}
+
+func newProjectServer() http.Handler {
+	s := &projectServer{
+		client: newHTTPClient(),
+		sem:    make(chan struct{}, max(serveConcurrent, 1)),
+		cache:  make(map[string]cachedSearch),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects", s.handleProjects)
+	return mux
+}
+
+func (s *projectServer) handleProjects(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), serveTimeout)
+	defer cancel()
+
+	in := r.URL.Query()
+	page := 1
+	if v := in.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid page %q", v))
+			return
+		}
+		page = n
+	}
+	target, params := buildURL(in.Get("q"), page)
+	u, err := url.Parse(target)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	q := u.Query()
+	for name, key := range serveParams {
+		if !in.Has(name) {
+			continue
+		}
+		v := in.Get(name)
+		params[key] = v
+		if v == "" || (name == "skills" && v == "all") {
+			q.Del(key)
+		} else {
+			q.Set(key, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+	target = u.String()
+
+	if data, ok := s.cached(target); ok {
+		w.Header().Set("X-Cache", "hit")
+		data.Projects = outputProjects(data.Projects)
+		writeAPIJSON(w, http.StatusOK, data)
+		return
+	}
+
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-ctx.Done():
+		writeAPIError(w, http.StatusServiceUnavailable, errors.New("too many scrapes in progress; try again later"))
+		return
+	}
+
+	result, err := Scrape(Options{Context: ctx, URL: target, Client: s.client, Strict: strict, Cookie: cookie, Locale: locale})
+	if err != nil {
+		status := http.StatusBadGateway
+		if ctx.Err() != nil {
+			status = http.StatusGatewayTimeout
+		}
+		writeAPIError(w, status, err)
+		return
+	}
+	data := OutputData{
+		SchemaVersion: schemaVersion,
+		Parameters:    params,
+		Search:        typedParams(params),
+		Projects:      result.Projects,
+		TotalResults:  result.TotalResults,
+		TotalPages:    result.TotalPages,
+	}
+	if data.Projects == nil {
+		data.Projects = []Project{}
+	}
+	s.store(target, data)
+	w.Header().Set("X-Cache", "miss")
+	data.Projects = outputProjects(data.Projects)
+	writeAPIJSON(w, http.StatusOK, data)
+}
+
+func (s *projectServer) cached(key string) (OutputData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cache[key]
+	if !ok || time.Now().After(c.expires) {
+		delete(s.cache, key)
+		return OutputData{}, false
+	}
+	return c.data, true
+}
+
+func (s *projectServer) store(key string, data OutputData) {
+	if serveCacheTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, c := range s.cache {
+		if now.After(c.expires) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = cachedSearch{data: data, expires: now.Add(serveCacheTTL)}
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
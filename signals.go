@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// interruptContext returns a context that is cancelled on the first SIGINT or
+// SIGTERM, so the scrape stops fetching and writes what it has. A second
+// signal exits immediately. stop uninstalls the handler.
+func interruptContext() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-sigs:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "\nInterrupted; writing the projects collected so far. Press Ctrl-C again to quit immediately.")
+		cancel()
+		select {
+		case <-sigs:
+			fmt.Fprintln(os.Stderr, "Interrupted again; exiting without output.")
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(done)
+		cancel()
+	}
+}
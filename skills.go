@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jobsAPIURL lists every skill ("job" in Freelancer's API) with its ID.
+const jobsAPIURL = "https://www.freelancer.com/api/projects/0.1/jobs/"
+
+// skillCatalogTTL is how long a cached skill list is trusted before it's
+// fetched again.
+const skillCatalogTTL = 7 * 24 * time.Hour
+
+// Skill is a Freelancer skill as used by the projectSkills filter.
+type Skill struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	SEOURL string `json:"seo_url"`
+}
+
+// skillCatalogPath is where the fetched skill list is cached.
+func skillCatalogPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "flparser", "skills.json"), nil
+}
+
+// loadSkillCatalog returns Freelancer's skill list, from the local cache when
+// it's fresh and from the jobs API otherwise.
+func loadSkillCatalog(client *http.Client) ([]Skill, error) {
+	path, pathErr := skillCatalogPath()
+	if pathErr == nil {
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < skillCatalogTTL {
+			if raw, err := os.ReadFile(path); err == nil {
+				var skills []Skill
+				if json.Unmarshal(raw, &skills) == nil && len(skills) > 0 {
+					return skills, nil
+				}
+			}
+		}
+	}
+
+	resp, err := client.Get(jobsAPIURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status code error: %d %s", resp.StatusCode, resp.Status)
+	}
+	var body struct {
+		Result []Skill `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding skill list: %w", err)
+	}
+	if len(body.Result) == 0 {
+		return nil, fmt.Errorf("skill list from %s is empty", jobsAPIURL)
+	}
+
+	if pathErr == nil {
+		if raw, err := json.Marshal(body.Result); err == nil {
+			os.MkdirAll(filepath.Dir(path), 0755)
+			os.WriteFile(path, raw, 0644)
+		}
+	}
+	return body.Result, nil
+}
+
+// scoreSkillMatch sets SkillMatch on each project to how many of the skills
+// requested with --skills it lists. Project skills are tag names, so they're
+// mapped to IDs through the skill catalog; without the catalog nothing is
+// scored. It does nothing when --skills is "all".
+func scoreSkillMatch(client *http.Client, projects []Project) {
+	if skills == "all" || len(projects) == 0 {
+		return
+	}
+	requested := make(map[int]bool)
+	for _, id := range strings.Split(skills, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(id)); err == nil {
+			requested[n] = true
+		}
+	}
+	if len(requested) == 0 {
+		return
+	}
+	catalog, err := loadSkillCatalog(client)
+	if err != nil {
+		log.Printf("Warning: couldn't load the skill list to score skill matches: %v", err)
+		return
+	}
+	ids := make(map[string]int, 2*len(catalog))
+	for _, s := range catalog {
+		ids[strings.ToLower(s.Name)] = s.ID
+		if s.SEOURL != "" {
+			ids[strings.ToLower(s.SEOURL)] = s.ID
+		}
+	}
+	for i := range projects {
+		matched := make(map[int]bool)
+		for _, name := range projects[i].Skills {
+			if id, ok := ids[strings.ToLower(name)]; ok && requested[id] {
+				matched[id] = true
+			}
+		}
+		projects[i].SkillMatch = len(matched)
+	}
+}
+
+// requireAllSkills keeps only the projects listing every skill in
+// --require-skills, the AND of the search's OR-based skill filter. Skills
+// are names, matched case-insensitively against the project's tags, or
+// IDs, which are looked up in the skill list.
+func requireAllSkills(client *http.Client, projects []Project, required []string) ([]Project, error) {
+	// Each required skill becomes the set of tag names that satisfy it.
+	var wanted []map[string]bool
+	var catalog map[int]Skill
+	for _, r := range required {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		id, err := strconv.Atoi(r)
+		if err != nil {
+			wanted = append(wanted, map[string]bool{strings.ToLower(r): true})
+			continue
+		}
+		if catalog == nil {
+			skills, err := loadSkillCatalog(client)
+			if err != nil {
+				return nil, fmt.Errorf("loading the skill list to look up skill ID %d: %w", id, err)
+			}
+			catalog = make(map[int]Skill, len(skills))
+			for _, s := range skills {
+				catalog[s.ID] = s
+			}
+		}
+		s, ok := catalog[id]
+		if !ok {
+			return nil, fmt.Errorf("unknown skill ID %d", id)
+		}
+		names := map[string]bool{strings.ToLower(s.Name): true}
+		if s.SEOURL != "" {
+			names[strings.ToLower(s.SEOURL)] = true
+		}
+		wanted = append(wanted, names)
+	}
+
+	var kept []Project
+	for _, p := range projects {
+		tags := make(map[string]bool, len(p.Skills))
+		for _, t := range p.Skills {
+			tags[strings.ToLower(t)] = true
+		}
+		all := true
+		for _, names := range wanted {
+			found := false
+			for name := range names {
+				if tags[name] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				all = false
+				break
+			}
+		}
+		if all {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
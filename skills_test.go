@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// withSkillCatalog points the skill catalog cache at a fresh directory
+// holding catalog, a JSON skill list, so nothing is fetched.
+func withSkillCatalog(t *testing.T, catalog string) {
+	t.Helper()
+	cache := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cache)
+	t.Setenv("HOME", cache)
+	path, err := skillCatalogPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(catalog), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRequireAllSkills(t *testing.T) {
+	withSkillCatalog(t, `[{"id": 3, "name": "PHP"}, {"id": 13, "name": "Python"}, {"id": 17, "name": "Golang", "seo_url": "go"}]`)
+	projects := []Project{
+		{Title: "PHP and Python", Skills: []string{"PHP", "Python"}},
+		{Title: "PHP only", Skills: []string{"PHP"}},
+		{Title: "All three", Skills: []string{"python", "Go", "php"}},
+		{Title: "No skills"},
+	}
+	tests := []struct {
+		name     string
+		required []string
+		want     []string
+	}{
+		{"one name", []string{"php"}, []string{"PHP and Python", "PHP only", "All three"}},
+		{"partial matches dropped", []string{"PHP", "Python"}, []string{"PHP and Python", "All three"}},
+		{"IDs", []string{"3", "13"}, []string{"PHP and Python", "All three"}},
+		{"ID matching the seo_url tag", []string{"17"}, []string{"All three"}},
+		{"names and IDs", []string{" php ", "17"}, []string{"All three"}},
+		{"none match", []string{"PHP", "Rust"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, err := requireAllSkills(http.DefaultClient, projects, tt.required)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := titles(kept); !slices.Equal(got, tt.want) {
+				t.Errorf("kept %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := requireAllSkills(http.DefaultClient, projects, []string{"999"}); err == nil {
+		t.Error("an unknown skill ID was accepted")
+	}
+}
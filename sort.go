@@ -0,0 +1,53 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// sortByFuncs maps each --sort-by value to a comparison ordering projects
+// best first. The sort is stable, so ties keep the scraped order.
+var sortByFuncs = map[string]func(a, b Project) int{
+	"skillmatch": func(a, b Project) int {
+		return cmp.Compare(b.SkillMatch, a.SkillMatch)
+	},
+	// Hourly rates and fixed budgets aren't comparable, so each type is
+	// sorted on its own: fixed projects first, then hourly.
+	"budget": func(a, b Project) int {
+		return cmp.Or(
+			cmp.Compare(priceTypeRank(a.PriceType), priceTypeRank(b.PriceType)),
+			cmp.Compare(b.BudgetMax, a.BudgetMax),
+		)
+	},
+	// Like budget, but with budgets converted to US dollars at the rough
+	// rates in usdRates, so mixed currencies rank together. Projects in a
+	// currency without a rate come last within their type.
+	"budget-normalized": func(a, b Project) int {
+		return cmp.Or(
+			cmp.Compare(priceTypeRank(a.PriceType), priceTypeRank(b.PriceType)),
+			cmp.Compare(normalizedBudget(b), normalizedBudget(a)),
+		)
+	},
+	"value": func(a, b Project) int {
+		return cmp.Compare(b.ValueScore, a.ValueScore)
+	},
+}
+
+// sortByNames lists the --sort-by values for messages.
+func sortByNames() string {
+	names := make([]string, 0, len(sortByFuncs))
+	for name := range sortByFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// sortProjects orders projects in place by --sort-by, if set.
+func sortProjects(projects []Project) {
+	if f := sortByFuncs[sortBy]; f != nil {
+		slices.SortStableFunc(projects, f)
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSortByBudgetKeepsTypesApart(t *testing.T) {
+	for _, by := range []string{"budget", "budget-normalized"} {
+		t.Run(by, func(t *testing.T) {
+			resetFlags(t)
+			setFlags(t, [][2]string{{"sort-by", by}})
+			projects := slices.Clone(mixedBudgets)
+			sortProjects(projects)
+			// A $30/hr rate is not "bigger" than a $200 fixed budget, and
+			// unknown types come last whatever their amount.
+			want := []string{"Fixed big", "Fixed small", "Hourly high", "Hourly low", "Unknown"}
+			if got := titles(projects); !slices.Equal(got, want) {
+				t.Errorf("sorted %q, want %q", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// budgetStats summarizes the budgets of projects sharing a price type and
+// currency. Hourly rates and fixed budgets are on different scales, as are
+// currencies, so they're never combined.
+type budgetStats struct {
+	PriceType string
+	Currency  string
+	Count     int
+	Min       float64
+	Median    float64
+	Mean      float64
+	Max       float64
+}
+
+// summarizeBudgets computes budgetStats over the budget midpoints of the
+// projects that have one, one entry per price type and currency, fixed
+// before hourly.
+func summarizeBudgets(projects []Project) []budgetStats {
+	type key struct{ priceType, currency string }
+	values := make(map[key][]float64)
+	for _, p := range projects {
+		if p.BudgetMax <= 0 || p.PriceType == priceTypeUnknown {
+			continue
+		}
+		k := key{p.PriceType, p.Currency}
+		values[k] = append(values[k], (p.BudgetMin+p.BudgetMax)/2)
+	}
+
+	var stats []budgetStats
+	for k, v := range values {
+		slices.Sort(v)
+		sum := 0.0
+		for _, x := range v {
+			sum += x
+		}
+		median := v[len(v)/2]
+		if len(v)%2 == 0 {
+			median = (v[len(v)/2-1] + v[len(v)/2]) / 2
+		}
+		stats = append(stats, budgetStats{
+			PriceType: k.priceType,
+			Currency:  k.currency,
+			Count:     len(v),
+			Min:       v[0],
+			Median:    median,
+			Mean:      sum / float64(len(v)),
+			Max:       v[len(v)-1],
+		})
+	}
+	slices.SortFunc(stats, func(a, b budgetStats) int {
+		return cmp.Or(cmp.Compare(priceTypeRank(a.PriceType), priceTypeRank(b.PriceType)), cmp.Compare(a.Currency, b.Currency))
+	})
+	return stats
+}
+
+// priceTypeRank orders price types fixed, hourly, unknown.
+func priceTypeRank(t string) int {
+	switch t {
+	case priceTypeFixed:
+		return 0
+	case priceTypeHourly:
+		return 1
+	}
+	return 2
+}
+
+// writeBudgetSummary prints summarizeBudgets as a short report for
+// --summary.
+func writeBudgetSummary(w io.Writer, projects []Project) {
+	stats := summarizeBudgets(projects)
+	if len(stats) == 0 {
+		fmt.Fprintln(w, "Budget summary: no projects with a parsed budget.")
+		return
+	}
+	fmt.Fprintln(w, "Budget summary (midpoint of each budget):")
+	for _, s := range stats {
+		unit := ""
+		if s.PriceType == priceTypeHourly {
+			unit = "/hr"
+		}
+		currency := s.Currency
+		if currency == "" {
+			currency = "unknown currency"
+		}
+		fmt.Fprintf(w, "  %s, %s: %d projects, min %s%s, median %s%s, mean %s%s, max %s%s\n",
+			s.PriceType, currency, s.Count,
+			formatAmount(s.Min, s.Currency), unit, formatAmount(s.Median, s.Currency), unit,
+			formatAmount(s.Mean, s.Currency), unit, formatAmount(s.Max, s.Currency), unit)
+	}
+}
+
+// skillCount is how many projects list a skill.
+type skillCount struct {
+	Skill string
+	Count int
+}
+
+// skillFrequencies tallies the skills of projects, counting each skill once
+// per project however it's capitalized, most frequent first and then by
+// name. A skill is reported as first spelled.
+func skillFrequencies(projects []Project) []skillCount {
+	index := make(map[string]int)
+	var counts []skillCount
+	for _, p := range projects {
+		listed := make(map[string]bool)
+		for _, skill := range p.Skills {
+			key := strings.ToLower(strings.TrimSpace(skill))
+			if key == "" || listed[key] {
+				continue
+			}
+			listed[key] = true
+			i, ok := index[key]
+			if !ok {
+				i = len(counts)
+				index[key] = i
+				counts = append(counts, skillCount{Skill: strings.TrimSpace(skill)})
+			}
+			counts[i].Count++
+		}
+	}
+	slices.SortStableFunc(counts, func(a, b skillCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(strings.ToLower(a.Skill), strings.ToLower(b.Skill)))
+	})
+	return counts
+}
+
+// writeSkillsReport writes skillFrequencies for --skills-report: as CSV when
+// path ends in .csv, otherwise as an aligned table, on stdout for "-".
+func writeSkillsReport(path string, projects []Project) error {
+	counts := skillFrequencies(projects)
+	if path == "-" {
+		writeSkillsTable(os.Stdout, counts, len(projects))
+		return nil
+	}
+	out, err := createOutput(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	format := "text"
+	if strings.HasSuffix(strings.ToLower(strings.TrimSuffix(path, ".gz")), ".csv") {
+		format = "csv"
+		w := csv.NewWriter(out)
+		w.Write([]string{"Skill", "Count"})
+		for _, c := range counts {
+			w.Write([]string{c.Skill, strconv.Itoa(c.Count)})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+	} else {
+		writeSkillsTable(out, counts, len(projects))
+	}
+	if err := out.Commit(); err != nil {
+		return err
+	}
+	log.Println("Skills report written to", path)
+	recordGenerated(path, format)
+	return nil
+}
+
+// writeSkillsTable prints counts as a table with each skill's share of the
+// projects.
+func writeSkillsTable(w io.Writer, counts []skillCount, projects int) {
+	if len(counts) == 0 {
+		fmt.Fprintln(w, "Skills report: no projects list any skills.")
+		return
+	}
+	width := len("Skill")
+	for _, c := range counts {
+		width = max(width, utf8.RuneCountInString(c.Skill))
+	}
+	fmt.Fprintf(w, "%-*s  %5s  %s\n", width, "Skill", "Count", "Share")
+	for _, c := range counts {
+		fmt.Fprintf(w, "%-*s  %5d  %4.0f%%\n", width, c.Skill, c.Count, 100*float64(c.Count)/float64(projects))
+	}
+}
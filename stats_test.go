@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// mixedBudgets holds fixed and hourly projects whose budgets would give
+// meaningless numbers if they were averaged together.
+var mixedBudgets = []Project{
+	{Title: "Fixed big", PriceType: priceTypeFixed, Currency: "USD", BudgetMin: 500, BudgetMax: 1000},
+	{Title: "Hourly low", PriceType: priceTypeHourly, Currency: "USD", BudgetMin: 10, BudgetMax: 20},
+	{Title: "Fixed small", PriceType: priceTypeFixed, Currency: "USD", BudgetMin: 100, BudgetMax: 200},
+	{Title: "Hourly high", PriceType: priceTypeHourly, Currency: "USD", BudgetMin: 20, BudgetMax: 30},
+	{Title: "Unknown", PriceType: priceTypeUnknown, Currency: "USD", BudgetMin: 5000, BudgetMax: 5000},
+}
+
+func TestSummarizeBudgetsKeepsTypesApart(t *testing.T) {
+	stats := summarizeBudgets(mixedBudgets)
+	want := []budgetStats{
+		{PriceType: priceTypeFixed, Currency: "USD", Count: 2, Min: 150, Median: 450, Mean: 450, Max: 750},
+		{PriceType: priceTypeHourly, Currency: "USD", Count: 2, Min: 15, Median: 20, Mean: 20, Max: 25},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("got %d summaries, want %d: %+v", len(stats), len(want), stats)
+	}
+	for i := range want {
+		if stats[i] != want[i] {
+			t.Errorf("summary %d = %+v, want %+v", i, stats[i], want[i])
+		}
+	}
+}
+
+func TestWriteBudgetSummaryUnits(t *testing.T) {
+	var sb strings.Builder
+	writeBudgetSummary(&sb, mixedBudgets)
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("summary has %d lines, want a heading and one per type:\n%s", len(lines), sb.String())
+	}
+	if fixed := lines[1]; !strings.Contains(fixed, "fixed, USD: 2 projects") || strings.Contains(fixed, "/hr") {
+		t.Errorf("fixed line = %q", fixed)
+	}
+	if hourly := lines[2]; !strings.Contains(hourly, "hourly, USD: 2 projects") || !strings.Contains(hourly, "mean 20/hr") {
+		t.Errorf("hourly line = %q", hourly)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// runSummary is the single end-of-run record --summary-json writes to
+// stderr, so wrapper scripts can read the outcome without parsing the
+// output file or the human-oriented log lines.
+type runSummary struct {
+	Projects    int    `json:"projects"`
+	Pages       int    `json:"pages"`
+	FilteredOut int    `json:"filtered_out"`
+	DurationMS  int64  `json:"duration_ms"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Values of runSummary.Status.
+const (
+	statusOK      = "ok"
+	statusPartial = "partial"
+	statusError   = "error"
+)
+
+// summary collects the run's stats as runScraper goes; runStart is when the
+// run began.
+var (
+	summary  = runSummary{Status: statusOK}
+	runStart = time.Now()
+)
+
+// writeSummary prints summary as one JSON line on stderr when
+// --summary-json is set.
+func writeSummary() {
+	if !summaryJSON {
+		return
+	}
+	summary.DurationMS = time.Since(runStart).Milliseconds()
+	line, _ := json.Marshal(summary)
+	fmt.Fprintln(os.Stderr, string(line))
+}
+
+// fatalf is log.Fatalf for the scrape run: it records the error in the
+// summary and writes it, and releases the projects --only-new claimed,
+// before exiting.
+func fatalf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	summary.Status = statusError
+	summary.Error = msg
+	log.Print(msg)
+	releaseSeen()
+	saveHAR()
+	stopProfiling()
+	writeSummary()
+	os.Exit(1)
+}
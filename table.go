@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI escapes used by the table format.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// tableMaxTitle caps the title column when the terminal width is unknown.
+const tableMaxTitle = 60
+
+// useColor reports whether f is a terminal and NO_COLOR isn't set
+// (https://no-color.org).
+func useColor(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(f)
+}
+
+// terminalWidth returns the width from $COLUMNS, or 0 when it isn't set.
+func terminalWidth() int {
+	n, _ := strconv.Atoi(os.Getenv("COLUMNS"))
+	return n
+}
+
+// writeTable prints the projects as an aligned table of title, budget, bids,
+// client country (when known) and time left, for a quick look without
+// writing a file. Long titles are truncated to fit the terminal.
+func writeTable(w io.Writer, data OutputData, color bool) {
+	withCountry := slices.ContainsFunc(data.Projects, func(p Project) bool { return p.EmployerCountry != "" })
+	header := []string{"Title", "Budget", "Bids"}
+	codes := []string{ansiCyan, ansiGreen, ""}
+	if withCountry {
+		header = append(header, "Client")
+		codes = append(codes, "")
+	}
+	header = append(header, "Time Left")
+	codes = append(codes, ansiDim)
+
+	rows := make([][]string, len(data.Projects))
+	for i, p := range data.Projects {
+		title := p.Title
+		if withIndex {
+			title = fmt.Sprintf("%d. %s", p.Index, title)
+		}
+		rows[i] = []string{title, priceText(p), p.BidsCount}
+		if withCountry {
+			var client string
+			if p.EmployerCountry != "" {
+				client = countryLabel(p.EmployerCountry)
+			}
+			rows[i] = append(rows[i], client)
+		}
+		rows[i] = append(rows[i], p.TimeLeft)
+	}
+
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i := 1; i < len(row); i++ {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[i]))
+		}
+	}
+	titleWidth := tableMaxTitle
+	if cols := terminalWidth(); cols > 0 {
+		rest := 0
+		for _, w := range widths[1:] {
+			rest += w + 2
+		}
+		titleWidth = max(cols-rest, widths[0])
+	}
+	for _, row := range rows {
+		row[0] = truncate(row[0], titleWidth)
+		widths[0] = max(widths[0], utf8.RuneCountInString(row[0]))
+	}
+
+	paint := func(code, s string) string {
+		if !color || code == "" {
+			return s
+		}
+		return code + s + ansiReset
+	}
+	line := func(cells []string, codes []string) {
+		var sb strings.Builder
+		for i, c := range cells {
+			if i > 0 {
+				sb.WriteString("  ")
+			}
+			pad := widths[i] - utf8.RuneCountInString(c)
+			if i == len(cells)-1 {
+				pad = 0
+			}
+			sb.WriteString(paint(codes[i], c))
+			sb.WriteString(strings.Repeat(" ", pad))
+		}
+		fmt.Fprintln(w, sb.String())
+	}
+
+	bold := make([]string, len(header))
+	for i := range bold {
+		bold[i] = ansiBold
+	}
+	line(header, bold)
+	for _, row := range rows {
+		line(row, codes)
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(w, paint(ansiDim, "(no projects)"))
+	}
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if n <= 0 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
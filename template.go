@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are available to every --template and --template-name
+// template, alongside the OutputData it's executed with.
+var templateFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"upper":    strings.ToUpper,
+	"lower":    strings.ToLower,
+	"truncate": truncate,
+	"amount":   formatAmount,
+	"price":    priceText,
+	"country":  countryLabel,
+	"date":     formatPostedAt,
+}
+
+// templateExt is the extension a template's output files get.
+const templateExt = ".tmpl"
+
+// templatePath returns the template to render the output with: --template,
+// or --template-name looked up in --output-template-dir. It's empty when
+// neither is set.
+func templatePath() (string, error) {
+	if templateFile != "" {
+		return templateFile, nil
+	}
+	if templateName == "" {
+		return "", nil
+	}
+	if templateDir == "" {
+		return "", fmt.Errorf("--template-name needs --output-template-dir")
+	}
+	path := filepath.Join(templateDir, templateName+templateExt)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if matches, _ := filepath.Glob(filepath.Join(templateDir, templateName+".*"+templateExt)); len(matches) > 0 {
+		return matches[0], nil
+	}
+	names, _ := templateNames(templateDir)
+	return "", fmt.Errorf("no template %q in %s (available: %s)", templateName, templateDir, strings.Join(names, ", "))
+}
+
+// templateNames lists the names of the templates in dir.
+func templateNames(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+templateExt))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		name := strings.TrimSuffix(filepath.Base(p), templateExt)
+		names[i] = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// templateOutputExt returns the extension for a template's output: the one
+// before .tmpl, so digest.md.tmpl writes .md files, or txt.
+func templateOutputExt(path string) string {
+	if ext := filepath.Ext(strings.TrimSuffix(filepath.Base(path), templateExt)); ext != "" {
+		return ext[1:]
+	}
+	return "txt"
+}
+
+// writeTemplate renders data with the template at path into filename,
+// reporting whether the file was written.
+func writeTemplate(filename, path string, data OutputData) bool {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(templateFuncs).ParseFiles(path)
+	if err != nil {
+		log.Println("Error parsing template:", err)
+		return false
+	}
+	file, err := createOutput(filename)
+	if err != nil {
+		log.Println("Error creating output file:", err)
+		return false
+	}
+	defer file.Close()
+	if err := tmpl.Execute(file, data); err != nil {
+		log.Println("Error rendering template:", err)
+		return false
+	}
+	if err := file.Commit(); err != nil {
+		log.Println("Error writing output file:", err)
+		return false
+	}
+	fmt.Println("Generated:", filename)
+	recordGenerated(filename, "template")
+	return true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+const (
+	// throttleStep is the least the delay grows by on a block, and what it
+	// shrinks by after each success.
+	throttleStep = 250 * time.Millisecond
+	// throttleRetries is how many times a blocked page is retried under
+	// --throttle-on-block before it counts as failed.
+	throttleRetries = 5
+)
+
+// requestThrottle spaces out search requests across all workers. The delay
+// is fixed at --delay unless --throttle-on-block is set, in which case it
+// doubles on every block (up to --max-delay) and shrinks by throttleStep
+// after every success (down to --min-delay). With --jitter each gap is
+// randomized around the delay so the requests aren't evenly spaced.
+type requestThrottle struct {
+	adaptive bool
+	min, max time.Duration
+	jitter   float64
+	rng      *rand.Rand
+
+	mu    sync.Mutex
+	delay time.Duration
+	next  time.Time
+}
+
+// throttle is the request throttle of the current scrape run.
+var throttle *requestThrottle
+
+func newRequestThrottle() *requestThrottle {
+	t := &requestThrottle{adaptive: throttleOnBlock, min: minDelay, max: maxDelay, delay: requestDelay}
+	if jitterPercent > 0 {
+		t.jitter = float64(jitterPercent) / 100
+		t.rng = newRand(seed)
+	}
+	if t.adaptive {
+		t.delay = min(max(t.delay, t.min), t.max)
+	}
+	return t
+}
+
+// wait blocks until this request's turn, which is one delay after the
+// previous request's, or until ctx is done.
+func (t *requestThrottle) wait(ctx context.Context) error {
+	t.mu.Lock()
+	now := time.Now()
+	at := now
+	if t.next.After(now) {
+		at = t.next
+	}
+	t.next = at.Add(t.jittered(t.delay))
+	t.mu.Unlock()
+
+	if at.Equal(now) {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jittered returns d moved by a random amount of up to --jitter percent
+// either way. t.mu must be held.
+func (t *requestThrottle) jittered(d time.Duration) time.Duration {
+	if t.rng == nil || d <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + t.jitter*(2*t.rng.Float64()-1)))
+}
+
+// newRand returns a random source seeded with seed, so a run can be
+// repeated with the same timing, or randomly seeded when seed is 0.
+func newRand(seed uint64) *rand.Rand {
+	if seed == 0 {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// record adjusts the delay to the outcome of one request, and reports
+// whether the request should be retried because it was blocked.
+func (t *requestThrottle) record(err error) (retry bool) {
+	if !t.adaptive {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err == nil {
+		t.delay = max(t.delay-throttleStep, t.min)
+		return false
+	}
+	if !errors.Is(err, ErrRateLimited) && !errors.Is(err, ErrChallenge) {
+		return false
+	}
+	t.delay = min(max(2*t.delay, throttleStep), t.max)
+	log.Printf("Warning: request blocked (%v); retrying at one request every %s", err, t.delay)
+	return true
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaJSON is the JSON Schema describing -X json output.
+//
+//go:embed schema.json
+var schemaJSON []byte
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <file>...",
+	Short: "Check JSON output files against the output schema",
+	Long: `Check previously written JSON output (including .gz and --bare files)
+against the schema built into this version, listing every missing field or
+mismatched value. It exits non-zero when any file doesn't validate.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := false
+		for _, path := range args {
+			problems, err := validateOutputFile(path)
+			switch {
+			case err != nil:
+				fmt.Printf("%s: %v\n", path, err)
+				failed = true
+			case len(problems) > 0:
+				fmt.Printf("%s: %d problems\n", path, len(problems))
+				for _, p := range problems {
+					fmt.Println("  " + p)
+				}
+				failed = true
+			default:
+				fmt.Printf("%s: valid (schema version %d)\n", path, schemaVersion)
+			}
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// validateOutputFile checks one output file against schemaJSON and returns
+// a description of each violation. A --bare file is checked as an array of
+// projects.
+func validateOutputFile(path string) ([]string, error) {
+	raw, err := readOutputBytes(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("embedded schema: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("not valid JSON: %w", err)
+	}
+
+	v := &schemaValidator{root: schema}
+	if _, ok := doc.([]any); ok {
+		v.check("", doc, map[string]any{"type": "array", "items": map[string]any{"$ref": "#/$defs/project"}})
+	} else {
+		if obj, ok := doc.(map[string]any); ok {
+			if n, ok := obj["schema_version"].(json.Number); ok {
+				if ver, err := n.Int64(); err == nil && ver > schemaVersion {
+					v.problems = append(v.problems, fmt.Sprintf("/schema_version: %d is newer than this build's %d; upgrade flparser to read it fully", ver, schemaVersion))
+				}
+			}
+		}
+		v.check("", doc, schema)
+	}
+	return v.problems, nil
+}
+
+// schemaValidator implements the subset of JSON Schema that schema.json
+// uses: type, required, properties, additionalProperties, items, $ref, const,
+// enum, minimum, maximum and the date-time format.
+type schemaValidator struct {
+	root     map[string]any
+	problems []string
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	if path == "" {
+		path = "/"
+	}
+	v.problems = append(v.problems, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *schemaValidator) check(path string, value any, schema map[string]any) {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved := v.resolve(ref)
+		if resolved == nil {
+			v.fail(path, "schema reference %s not found", ref)
+			return
+		}
+		schema = resolved
+	}
+
+	if t, ok := schema["type"].(string); ok && !hasType(value, t) {
+		v.fail(path, "expected %s, got %s", t, jsonType(value))
+		return
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(value, c) {
+		v.fail(path, "must be %v, got %v", c, value)
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(value, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "%v is not one of %v", value, enum)
+		}
+	}
+	if n, ok := value.(json.Number); ok {
+		f, _ := n.Float64()
+		if min, ok := schema["minimum"].(float64); ok && f < min {
+			v.fail(path, "%v is below the minimum %v", n, min)
+		}
+		if max, ok := schema["maximum"].(float64); ok && f > max {
+			v.fail(path, "%v is above the maximum %v", n, max)
+		}
+	}
+	if s, ok := value.(string); ok && schema["format"] == "date-time" {
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			v.fail(path, "%q is not an RFC 3339 date-time", s)
+		}
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := value[name]; !present {
+						v.fail(path, "missing required field %q", name)
+					}
+				}
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := path + "/" + escapePointer(k)
+			if ps, ok := props[k].(map[string]any); ok {
+				v.check(child, value[k], ps)
+			} else if additional != nil {
+				v.check(child, value[k], additional)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				v.check(fmt.Sprintf("%s/%d", path, i), item, items)
+			}
+		}
+	}
+}
+
+// resolve looks up a local "#/..." reference in the root schema.
+func (v *schemaValidator) resolve(ref string) map[string]any {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil
+	}
+	var node any = v.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = m[strings.NewReplacer("~1", "/", "~0", "~").Replace(part)]
+	}
+	m, _ := node.(map[string]any)
+	return m
+}
+
+// hasType reports whether a value decoded with UseNumber has JSON Schema type t.
+func hasType(value any, t string) bool {
+	switch t {
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := n.Int64()
+		return err == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	default:
+		return jsonType(value) == t
+	}
+}
+
+// jsonType names the JSON type of a decoded value.
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// jsonEqual compares a document value with a schema constant, which was
+// decoded without UseNumber.
+func jsonEqual(a, b any) bool {
+	if n, ok := a.(json.Number); ok {
+		f, err := n.Float64()
+		bf, isNum := b.(float64)
+		return err == nil && isNum && f == bf
+	}
+	return a == b
+}
+
+// escapePointer escapes a key for use in a JSON Pointer.
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}